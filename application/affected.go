@@ -0,0 +1,211 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/sumartian-studios/snake/configuration"
+	"github.com/sumartian-studios/snake/graph"
+)
+
+// A Snake flag of a command forwarding its other arguments to a tool.
+type forwardedFlag struct {
+	// The flag name including the dashes (ex. --since).
+	name string
+
+	// True if the flag requires a value (--name value or --name=value). Values of
+	// short flags can also be attached (-j4).
+	hasValue bool
+
+	// Called with the value of the flag (empty for boolean flags).
+	set func(value string) error
+}
+
+// Extract Snake flags from arguments that are otherwise forwarded to the
+// underlying tool. Arguments after "--" are forwarded as-is.
+func extractFlags(args []string, flags []forwardedFlag) ([]string, error) {
+	rest := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			return append(rest, args[i+1:]...), nil
+		}
+
+		matched := false
+
+		for _, f := range flags {
+			value := ""
+
+			if arg == f.name {
+				if f.hasValue {
+					if i+1 >= len(args) {
+						return nil, usageError(fmt.Errorf("%s requires a value", f.name))
+					}
+
+					value = args[i+1]
+					i++
+				}
+			} else if f.hasValue && strings.HasPrefix(arg, f.name+"=") {
+				value = strings.TrimPrefix(arg, f.name+"=")
+			} else if f.hasValue && !strings.HasPrefix(f.name, "--") && strings.HasPrefix(arg, f.name) {
+				value = strings.TrimPrefix(arg, f.name)
+			} else {
+				continue
+			}
+
+			if err := f.set(value); err != nil {
+				return nil, usageError(fmt.Errorf("%s: %w", f.name, err))
+			}
+
+			matched = true
+			break
+		}
+
+		if !matched {
+			rest = append(rest, arg)
+		}
+	}
+
+	return rest, nil
+}
+
+// Run git in the root directory and return the listed files.
+func (app *Application) gitFiles(args ...string) ([]string, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("git", append([]string{"-C", app.rootDir}, args...)...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+
+	files := []string{}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			files = append(files, line)
+		}
+	}
+
+	return files, nil
+}
+
+// Returns the files (relative to the root directory) changed since a revision
+// including uncommitted and untracked files.
+func (app *Application) changedFiles(since string) ([]string, error) {
+	changed, err := app.gitFiles("diff", "--name-only", "--relative", since)
+
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := app.gitFiles("ls-files", "--others", "--exclude-standard")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(changed, untracked...), nil
+}
+
+// Returns true if the file belongs to the target directory or its resources.
+func targetOwnsFile(t *configuration.Target, file string) bool {
+	// A target at the root of the project owns every file.
+	if dir := path.Clean(t.Path); dir == "." || file == dir || strings.HasPrefix(file, dir+"/") {
+		return true
+	}
+
+	if t.Features == nil {
+		return false
+	}
+
+	for _, feat := range *t.Features {
+		if feat.Resources == nil {
+			continue
+		}
+
+		for _, resource := range *feat.Resources {
+			for _, pattern := range resource.Files {
+				pattern = strings.ReplaceAll(pattern, "${CMAKE_SOURCE_DIR}/", "")
+				pattern = strings.ReplaceAll(pattern, "${TARGET_SOURCE_DIR}", path.Clean(t.Path))
+
+				if pattern == file {
+					return true
+				}
+
+				// Resource files may be regular expressions.
+				if matched, err := regexp.MatchString("^"+pattern+"$", file); err == nil && matched {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// Returns the targets affected by changes since a revision in declaration order.
+func (app *Application) affectedTargets(since string) ([]*configuration.Target, error) {
+	files, err := app.changedFiles(since)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return app.targetsAffectedBy(files), nil
+}
+
+// Returns the targets affected by the given files (relative to the root directory) in
+// declaration order. A target is affected if one of its files changed or if it
+// depends on an affected target.
+func (app *Application) targetsAffectedBy(files []string) []*configuration.Target {
+	affected := []*configuration.Target{}
+
+	if app.cfg.Targets == nil {
+		return affected
+	}
+
+	targets := *app.cfg.Targets
+	changed := []string{}
+
+	for _, file := range files {
+		// Every target depends on the project configuration.
+		if file == ".snake.yml" || file == "CMakeLists.txt" {
+			for i := range targets {
+				affected = append(affected, &targets[i])
+			}
+
+			return affected
+		}
+
+		for i := range targets {
+			if targetOwnsFile(&targets[i], file) {
+				changed = append(changed, targets[i].Name)
+			}
+		}
+	}
+
+	reachable := graph.New(app.cfg).Reachable(changed, true)
+
+	for i := range targets {
+		if reachable[targets[i].Name] {
+			affected = append(affected, &targets[i])
+		}
+	}
+
+	return affected
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sumartian-studios/snake/configuration"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExtractFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		want  []string
+		since string
+		jobs  string
+		quiet bool
+		err   string
+	}{
+		{"no flags", []string{"-R", "x"}, []string{"-R", "x"}, "", "", false, ""},
+		{"separate value", []string{"--since", "HEAD~1", "-R", "x"}, []string{"-R", "x"}, "HEAD~1", "", false, ""},
+		{"equal value", []string{"--since=main", "-V"}, []string{"-V"}, "main", "", false, ""},
+		{"attached short value", []string{"-j4", "-V"}, []string{"-V"}, "", "4", false, ""},
+		{"boolean", []string{"-V", "--quiet"}, []string{"-V"}, "", "", true, ""},
+		{"similar long flag", []string{"--sincere"}, []string{"--sincere"}, "", "", false, ""},
+		{"passthrough", []string{"-j2", "--", "--since", "x"}, []string{"--since", "x"}, "", "2", false, ""},
+		{"missing value", []string{"-V", "--since"}, nil, "", "", false, "--since requires a value"},
+		{"invalid value", []string{"-j", "many"}, nil, "", "", false, "-j: not a number"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			since, jobs, quiet := "", "", false
+
+			got, err := extractFlags(test.args, []forwardedFlag{
+				{"--since", true, func(value string) error { since = value; return nil }},
+				{"-j", true, func(value string) error {
+					if value == "many" {
+						return errors.New("not a number")
+					}
+
+					jobs = value
+					return nil
+				}},
+				{"--quiet", false, func(string) error { quiet = true; return nil }},
+			})
+
+			if len(test.err) > 0 {
+				var exitErr *ExitError
+
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %s", err, test.err)
+				}
+
+				if !errors.As(err, &exitErr) || exitErr.Code != ExitUsage {
+					t.Errorf("got %v, want a usage error", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+
+			if since != test.since || jobs != test.jobs || quiet != test.quiet {
+				t.Errorf("got since=%q jobs=%q quiet=%v, want since=%q jobs=%q quiet=%v",
+					since, jobs, quiet, test.since, test.jobs, test.quiet)
+			}
+		})
+	}
+}
+
+// Returns an application with the configuration of the given YAML.
+func configuredApp(t *testing.T, config string) *Application {
+	var cfg configuration.Configuration
+
+	if err := yaml.Unmarshal([]byte(config), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	return &Application{cfg: &cfg}
+}
+
+// Returns the names of the targets.
+func targetNames(targets []*configuration.Target) []string {
+	names := []string{}
+
+	for _, t := range targets {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
+
+func TestTargetOwnsFile(t *testing.T) {
+	app := configuredApp(t, `
+Targets:
+  - name: root
+    path: .
+  - name: a
+    path: lib/a/
+  - name: ui
+    path: ui
+    features:
+      - resources:
+          - files:
+              - ${CMAKE_SOURCE_DIR}/assets/logo.png
+              - ${TARGET_SOURCE_DIR}/../shared/theme.qss
+              - qml/.*\.qml
+`)
+
+	targets := *app.cfg.Targets
+
+	tests := []struct {
+		name   string
+		target int
+		file   string
+		want   bool
+	}{
+		{"root owns every file", 0, "lib/a/a.cpp", true},
+		{"file in the directory", 1, "lib/a/a.cpp", true},
+		{"nested file", 1, "lib/a/src/b.cpp", true},
+		{"directory with a similar name", 1, "lib/ab/a.cpp", false},
+		{"other directory", 1, "ui/main.cpp", false},
+		{"source directory resource", 2, "assets/logo.png", true},
+		{"target directory resource", 2, "ui/../shared/theme.qss", true},
+		{"regular expression resource", 2, "qml/Main.qml", true},
+		{"unmatched resource", 2, "qml/Main.js", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := targetOwnsFile(&targets[test.target], test.file); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTargetsAffectedBy(t *testing.T) {
+	libraries := `
+Targets:
+  - name: core
+    path: lib/core
+  - name: ui
+    path: lib/ui
+    features:
+      - libraries:
+          - type: public
+            targets: [core]
+  - name: app
+    path: app
+    features:
+      - libraries:
+          - type: private
+            targets: [ui]
+  - name: tool
+    path: tool
+`
+
+	tests := []struct {
+		name   string
+		config string
+		files  []string
+		want   []string
+	}{
+		{"configuration", libraries, []string{"README.md", ".snake.yml"}, []string{"core", "ui", "app", "tool"}},
+		{"build script", libraries, []string{"CMakeLists.txt"}, []string{"core", "ui", "app", "tool"}},
+		{"dependents", libraries, []string{"lib/core/core.cpp"}, []string{"core", "ui", "app"}},
+		{"declaration order", libraries, []string{"tool/main.cpp", "lib/ui/ui.cpp"}, []string{"ui", "app", "tool"}},
+		{"unowned file", libraries, []string{"README.md"}, []string{}},
+		{"no targets", "Targets:\n", []string{".snake.yml"}, []string{}},
+		{"root target", "Targets:\n  - name: app\n    path: .\n  - name: lib\n    path: lib\n", []string{"main.cpp"}, []string{"app"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := configuredApp(t, test.config)
+
+			if got := targetNames(app.targetsAffectedBy(test.files)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
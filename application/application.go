@@ -7,8 +7,9 @@ package application
 
 import (
 	"bytes"
-	"embed"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -18,6 +19,8 @@ import (
 	"github.com/fxamacker/cbor/v2"
 	"github.com/spf13/cobra"
 	"github.com/sumartian-studios/snake/configuration"
+	"github.com/sumartian-studios/snake/distribution"
+	"github.com/sumartian-studios/snake/utilities"
 	"gopkg.in/yaml.v3"
 )
 
@@ -34,6 +37,15 @@ type Storage struct {
 
 	// Index of the active profile.
 	ProfileIndex int `json:"ProfileIndex"`
+
+	// Builds recorded per profile name (oldest first).
+	Builds map[string][]BuildRecord `json:"Builds"`
+
+	// Test cases discovered per profile name and target.
+	Discovered map[string]map[string]DiscoveredTests `json:"TestDiscovery"`
+
+	// Test results recorded per profile name and test name (oldest first).
+	Tests map[string]map[string][]TestRecord `json:"Tests"`
 }
 
 // Application represents our global state manager.
@@ -46,9 +58,6 @@ type Application struct {
 	// Snake build database.
 	db Storage
 
-	// The embedded CMake files.
-	dataZip *embed.FS
-
 	// Path to snake directory.
 	snakeDir string
 
@@ -70,11 +79,21 @@ type Application struct {
 	// True if running in interactive mode.
 	interactive bool
 
+	// Paths of the plugin executables by command name.
+	plugins map[string]string
+
+	// True while lifecycle hooks are running. Commands started by hooks do not run
+	// hooks themselves.
+	hooking bool
+
 	// True if the storage needs to be saved.
 	storagePendingSave bool
 
 	// True if verbose mode enabled.
 	verbose bool
+
+	// Context used to run subprocesses. Defaults to the command context.
+	ctx context.Context
 }
 
 // Global instance of our application.
@@ -111,7 +130,7 @@ func (app *Application) initSlow() error {
 	}
 
 	if err = app.loadConfiguration(); err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return configurationError(fmt.Errorf("failed to load configuration: %w", err))
 	}
 
 	// Create the build directory if it does not exist.
@@ -120,7 +139,7 @@ func (app *Application) initSlow() error {
 	}
 
 	if err := app.loadStorage(); err != nil {
-		return err
+		return configurationError(fmt.Errorf("failed to load storage: %w", err))
 	}
 
 	return nil
@@ -140,7 +159,7 @@ func (app *Application) loadConfiguration() error {
 		return err
 	}
 
-	return nil
+	return app.validateHooks()
 }
 
 // Fast path initialization.
@@ -154,7 +173,7 @@ func (app *Application) initFast() error {
 	}
 
 	if err := app.loadStorage(); err != nil {
-		return err
+		return configurationError(fmt.Errorf("failed to load storage: %w", err))
 	}
 
 	return nil
@@ -163,17 +182,83 @@ func (app *Application) initFast() error {
 // Track the time taken by a function.
 func (app *Application) timeTrack(start time.Time, name string) {
 	elapsed := time.Since(start)
-	fmt.Printf("%s took %s\n", name, elapsed)
+	fmt.Fprintf(app.OutOrStdout(), "%s took %s\n", name, elapsed)
 }
 
-// Launch a subprocess.
+// Returns the context used to run subprocesses.
+func (app *Application) context() context.Context {
+	if app.ctx != nil {
+		return app.ctx
+	}
+
+	if ctx := app.Context(); ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}
+
+// Launch a build tool. The returned error carries the exit code of the child.
+// Interrupting Snake interrupts the tool and all of its children.
 func (app *Application) launch(program string, args ...string) error {
+	return app.launchWith(app.OutOrStdout(), os.Environ(), program, args...)
+}
+
+// Launch a build tool writing its standard output to a writer.
+func (app *Application) launchWith(stdout io.Writer, env []string, program string, args ...string) error {
 	cmd := exec.Command(program, args...)
-	cmd.Env = os.Environ()
+	cmd.Env = env
+	cmd.Stderr = app.ErrOrStderr()
+	cmd.Stdout = stdout
+
+	if err := utilities.RunCommand(app.context(), cmd, false); err != nil {
+		return subprocessError(program, err)
+	}
+
+	return nil
+}
+
+// Launch a program attached to the terminal (ex. an executable target).
+func (app *Application) launchInteractive(cmd *exec.Cmd) error {
 	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	cmd.Stdout = os.Stdout
-	return cmd.Run()
+	cmd.Stderr = app.ErrOrStderr()
+	cmd.Stdout = app.OutOrStdout()
+
+	if err := utilities.RunCommand(app.context(), cmd, true); err != nil {
+		return subprocessError(filepath.Base(cmd.Path), err)
+	}
+
+	return nil
+}
+
+// Run a command line. The command is cancelled when Snake receives SIGINT or
+// SIGTERM and the signal is forwarded to the running tools.
+func (app *Application) execute(args []string) error {
+	ctx, stop := utilities.NotifyContext(app.context())
+
+	previous := app.ctx
+	app.ctx = ctx
+
+	defer func() {
+		app.ctx = previous
+		stop()
+	}()
+
+	if ok, err := app.dispatchPlugin(args); ok {
+		return err
+	}
+
+	app.SetArgs(args)
+	return app.Execute()
+}
+
+// Run a Snake command as if it was passed on the command-line.
+func (app *Application) run(args ...string) error {
+	// Need to reset the flags after each call otherwise
+	// our flags will be stuck.
+	app.resetFlags()
+
+	return app.execute(args)
 }
 
 // Load storage from disk into memory.
@@ -230,28 +315,64 @@ func (app *Application) saveStorage() error {
 	return nil
 }
 
-// Start the application and parse command-line arguments.
-func Execute(dataZip *embed.FS) error {
-	if dataZip == nil {
-		return fmt.Errorf("corrupted Snake archive file")
+// Start the application and parse command-line arguments. Use ExitCode to
+// retrieve the process exit code from the returned error.
+func Execute() error {
+	args := os.Args[1:]
+
+	if app.needsPlugins(args) {
+		app.addPlugins(args)
+	}
+
+	return app.execute(args)
+}
+
+// Create an application operating on a project without the command-line interface.
+// Relative directories are resolved from the working directory.
+func New(rootDir string, snakeDir string) *Application {
+	return &Application{
+		rootDir:  rootDir,
+		snakeDir: snakeDir,
+		plugins:  map[string]string{},
 	}
+}
 
-	app.dataZip = dataZip
-	app.Execute()
+// Load the configuration and the storage of the project.
+func (app *Application) Load() error {
+	return app.initSlow()
+}
 
-	return nil
+// Set the context used to run subprocesses.
+func (app *Application) SetContext(ctx context.Context) {
+	app.ctx = ctx
+}
+
+// Enable verbose logging.
+func (app *Application) SetVerbose(verbose bool) {
+	app.verbose = verbose
 }
 
 func init() {
+	if len(VersionStr) == 0 {
+		VersionStr = distribution.Version()
+	}
+
+	app.plugins = map[string]string{}
+
 	app.Command = cobra.Command{
 		Use:                "snake",
 		Version:            VersionStr,
 		Short:              "Snake is a C++ build system and CI/CD tool designed to interoperate with CMake\nand other third-party applications and libraries.",
 		SilenceUsage:       true,
+		SilenceErrors:      true,
 		DisableFlagParsing: false,
 		RunE:               startInteractiveMode,
 	}
 
+	app.Command.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return usageError(err)
+	})
+
 	app.Command.PersistentFlags().BoolP("help", "h", false, "Show help information")
 	app.Command.PersistentFlags().BoolP("version", "v", false, "Show version information")
 
@@ -260,5 +381,6 @@ func init() {
 	app.Command.PersistentFlags().BoolVar(&app.verbose, "verbose", false, "Enable verbose logging")
 
 	app.Command.AddCommand(deployCmd, buildCmd, testCmd, configureCmd, installCmd, cleanCmd,
-		packageCmd, runCmd, listProfilesCmd, listOptionsCmd, listTargetsCmd, docCmd, mutateCmd, formatCmd, generateCmd, newCmd)
+		packageCmd, runCmd, listProfilesCmd, listOptionsCmd, listTargetsCmd, docCmd, mutateCmd, formatCmd, generateCmd, newCmd, graphCmd, whyCmd, watchCmd, statsCmd,
+		errorsCmd)
 }
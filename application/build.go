@@ -6,36 +6,325 @@
 package application
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/sumartian-studios/snake/configuration"
+	"github.com/sumartian-studios/snake/utilities"
 )
 
-var buildCmd = &cobra.Command{
-	Use:                "build",
-	Short:              "Build a target using the build system or run a script",
-	DisableFlagParsing: true,
-	RunE: func(c *cobra.Command, args []string) error {
-		defer app.timeTrack(time.Now(), "Build")
+var buildJobsFlag int
+var buildKeepGoingFlag bool
+var buildCleanFirstFlag bool
+var buildAffectedFlag bool
+var buildSinceFlag string
+var buildDiagnosticsOutFlag string
+
+// Returns the targets known to the generated build system. Targets disabled by their
+// requirement in the current profile are never added to the build system.
+func (app *Application) buildSystemTargets() (map[string]bool, error) {
+	output, err := exec.CommandContext(app.context(), "ninja", "-C", app.db.ProfilePath, "-t", "targets", "all").Output()
+
+	if err != nil {
+		return nil, err
+	}
+
+	targets := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+
+	for scanner.Scan() {
+		if name, _, found := strings.Cut(scanner.Text(), ": "); found {
+			targets[name] = true
+		}
+	}
+
+	return targets, scanner.Err()
+}
+
+// Returns true if the build system was generated after the last changes to the
+// configuration and the CMakeLists.txt.
+func (app *Application) buildSystemCurrent() bool {
+	generated, err := os.Stat(filepath.Join(app.db.ProfilePath, "build.ninja"))
+
+	if err != nil {
+		return false
+	}
+
+	for _, input := range []string{app.configPath, filepath.Join(app.rootDir, "CMakeLists.txt")} {
+		if info, err := os.Stat(input); err != nil || info.ModTime().After(generated.ModTime()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Validate the requested target and script names and return the ones that can be built.
+func (app *Application) resolveBuildTargets(names []string) ([]string, error) {
+	targets := map[string]*configuration.Target{}
+	scripts := map[string]bool{}
+	candidates := []string{}
+
+	if app.cfg.Targets != nil {
+		for i, t := range *app.cfg.Targets {
+			targets[t.Name] = &(*app.cfg.Targets)[i]
+			candidates = append(candidates, t.Name)
+		}
+	}
+
+	if app.cfg.Scripts != nil {
+		for _, s := range *app.cfg.Scripts {
+			scripts[s.Name] = true
+			candidates = append(candidates, s.Name)
+		}
+	}
+
+	for _, name := range names {
+		if targets[name] == nil && !scripts[name] {
+			if suggestions := utilities.Suggest(name, candidates); len(suggestions) > 0 {
+				return nil, usageError(fmt.Errorf("unknown target or script: %s (did you mean %s?)",
+					name, strings.Join(suggestions, ", ")))
+			}
+
+			return nil, usageError(fmt.Errorf("unknown target or script (see 'snake targets'): %s", name))
+		}
+	}
+
+	// Targets missing from an outdated build system (ex. added since the last
+	// configuration) are passed to the build tool which re-generates it first.
+	var available map[string]bool
+
+	if len(names) > 0 && app.buildSystemCurrent() {
+		// The build system may not exist yet; let the build tool report it.
+		available, _ = app.buildSystemTargets()
+	}
+
+	resolved := []string{}
+
+	for _, name := range names {
+		if t := targets[name]; t != nil {
+			if t.Type == "header-library" {
+				fmt.Fprintf(app.ErrOrStderr(), "warning: %s is a header library and has nothing to build\n", name)
+				continue
+			}
+
+			if available != nil && !available[name] && len(t.Requirement) > 0 {
+				fmt.Fprintf(app.ErrOrStderr(), "warning: %s is disabled in the current profile (requirement: %s)\n",
+					name, t.Requirement)
+				continue
+			}
+		}
+
+		resolved = append(resolved, name)
+	}
+
+	return resolved, nil
+}
+
+// Options of a build.
+type BuildOptions struct {
+	// Targets or scripts to build. Everything is built if empty.
+	Targets []string
+
+	// Options passed to the build tool.
+	Forwarded []string
+
+	// Maximum number of concurrent build jobs (0 lets the build tool decide).
+	Jobs int
+
+	// Keep building as much as possible after a failure.
+	KeepGoing bool
+
+	// Clean the build outputs before building.
+	CleanFirst bool
+
+	// Also build the targets affected by the changes since this revision.
+	Since string
+
+	// Write the compiler diagnostics to this path (JSON or SARIF if the name
+	// contains ".sarif").
+	DiagnosticsOut string
+}
+
+// Build targets using the build system.
+func (app *Application) Build(opts BuildOptions) error {
+	defer app.timeTrack(time.Now(), "Build")
+
+	if err := app.initSlow(); err != nil {
+		return err
+	}
+
+	if app.db.ProfileIndex == -1 || len(app.db.ProfilePath) == 0 {
+		return configurationError(errors.New("you must re-configure this project (snake configure)"))
+	}
+
+	names, forwarded := opts.Targets, opts.Forwarded
+	out := app.OutOrStdout()
 
-		err := app.initFast()
+	if len(opts.Since) > 0 {
+		targets, err := app.affectedTargets(opts.Since)
 
 		if err != nil {
 			return err
 		}
 
-		cmd := exec.Command("cmake", append([]string{"--build", app.db.ProfilePath, "--"}, args...)...)
-		cmd.Env = os.Environ()
+		affected := []string{}
 
-		if app.db.ProfileIndex == -1 {
-			return errors.New("you must re-configure this project (snake configure)")
+		for _, t := range targets {
+			// Header libraries do not produce anything to build.
+			if t.Type != "header-library" {
+				affected = append(affected, t.Name)
+			}
 		}
 
-		cmd.Stderr, cmd.Stdout, cmd.Stdin = os.Stderr, os.Stdout, os.Stdin
+		if len(affected) == 0 && len(names) == 0 {
+			fmt.Fprintln(out, "No affected targets since", opts.Since)
+			return nil
+		}
+
+		fmt.Fprintln(out, "Affected targets:", strings.Join(affected, " "))
+
+		names = append(names, affected...)
+	}
+
+	requested := len(names) > 0
+
+	names, err := app.resolveBuildTargets(names)
+
+	if err != nil {
+		return err
+	}
+
+	if requested && len(names) == 0 {
+		fmt.Fprintln(out, "Nothing to build")
+		return nil
+	}
+
+	args := []string{"--build", app.db.ProfilePath}
+
+	if len(names) > 0 {
+		args = append(args, append([]string{"--target"}, names...)...)
+	}
+
+	if opts.Jobs > 0 {
+		args = append(args, "--parallel", strconv.Itoa(opts.Jobs))
+	}
+
+	if opts.CleanFirst {
+		args = append(args, "--clean-first")
+	}
+
+	if app.verbose {
+		args = append(args, "--verbose")
+	}
+
+	// Ninja stops after the first failure unless told otherwise.
+	if opts.KeepGoing {
+		forwarded = append([]string{"-k", "0"}, forwarded...)
+	}
+
+	if len(forwarded) > 0 {
+		args = append(args, append([]string{"--"}, forwarded...)...)
+	}
+
+	if err := app.runHooks("pre-build"); err != nil {
+		return err
+	}
+
+	list, err := app.launchCollecting("cmake", args...)
+
+	if saveErr := app.saveDiagnostics(list); saveErr != nil && app.verbose {
+		fmt.Fprintln(app.ErrOrStderr(), "warning: unable to save build diagnostics:", saveErr)
+	}
+
+	if len(list) > 0 {
+		app.printDiagnosticsSummary(list, summaryErrorLimit)
+	}
+
+	if len(opts.DiagnosticsOut) > 0 {
+		if err := app.writeDiagnostics(list, opts.DiagnosticsOut); err != nil {
+			return err
+		}
+	}
+
+	if err != nil {
+		return err
+	}
 
-		return cmd.Run()
+	app.recordLastBuild()
+
+	// The build succeeded even if the tests cannot be discovered unless the
+	// configuration is invalid.
+	var exitErr *ExitError
+
+	if err := app.discoverTests(); errors.As(err, &exitErr) {
+		return err
+	} else if err != nil {
+		fmt.Fprintln(app.ErrOrStderr(), "warning: unable to discover the tests:", err)
+	}
+
+	return app.runHooks("post-build")
+}
+
+var buildCmd = &cobra.Command{
+	Use:   "build [target|script...] [-- build-tool-options]",
+	Short: "Build a target using the build system or run a script",
+	RunE: func(c *cobra.Command, args []string) error {
+		opts := BuildOptions{
+			Targets:        args,
+			Jobs:           buildJobsFlag,
+			KeepGoing:      buildKeepGoingFlag,
+			CleanFirst:     buildCleanFirstFlag,
+			DiagnosticsOut: buildDiagnosticsOutFlag,
+		}
+
+		if dash := c.ArgsLenAtDash(); dash != -1 {
+			opts.Targets, opts.Forwarded = args[:dash], args[dash:]
+		}
+
+		if buildAffectedFlag || c.Flags().Changed("since") {
+			opts.Since = buildSinceFlag
+		}
+
+		return app.Build(opts)
 	},
 }
+
+func init() {
+	buildCmd.Flags().IntVarP(&buildJobsFlag,
+		"jobs", "j", 0,
+		"Maximum number of concurrent build jobs")
+
+	buildCmd.Flags().BoolVarP(&buildKeepGoingFlag,
+		"keep-going", "k", false,
+		"Keep building as much as possible after a failure")
+
+	buildCmd.Flags().BoolVar(&buildCleanFirstFlag,
+		"clean-first", false,
+		"Clean the build outputs before building")
+
+	buildCmd.Flags().BoolVar(&buildAffectedFlag,
+		"affected", false,
+		"Only build the targets affected by local changes")
+
+	buildCmd.Flags().StringVar(&buildSinceFlag,
+		"since", "HEAD",
+		"Revision used to detect affected targets (implies --affected)")
+
+	buildCmd.Flags().StringVar(&buildDiagnosticsOutFlag,
+		"diagnostics-out", "",
+		"Write the compiler diagnostics to a JSON or SARIF (*.sarif) file")
+
+	buildCmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return usageError(fmt.Errorf("%w (pass build tool options after '--')", err))
+	})
+}
@@ -6,14 +6,11 @@
 package application
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -21,25 +18,26 @@ import (
 )
 
 var forceUpdateFlag bool
+var forceFlag bool
 var profileFlag string
 var traceFlag bool
 
-// Returns the profile and if it has changed.
-func getOrUpdateCurrentProfile() (*configuration.Profile, bool, error) {
+// Returns the requested profile (or the current one if empty) and if it has changed.
+func (app *Application) getOrUpdateCurrentProfile(name string) (*configuration.Profile, bool, error) {
 	var currentProfile *configuration.Profile = nil
 	var currentProfileExists = false
 
-	if currentProfileExists, currentProfile = app.getCurrentProfile(); currentProfileExists {
-		fmt.Println("Reusing profile:", currentProfile.Name)
+	if len(app.cfg.Profiles) < 1 {
+		return nil, false, configurationError(fmt.Errorf("project does not have any profiles: %s", name))
 	}
 
-	if len(app.cfg.Profiles) < 1 {
-		return nil, false, fmt.Errorf("project does not have any profiles: %s", profileFlag)
+	if currentProfileExists, currentProfile = app.getCurrentProfile(); currentProfileExists {
+		fmt.Fprintln(app.OutOrStdout(), "Reusing profile:", currentProfile.Name)
 	}
 
 	// Look for a profile matching flag name.
 	for i, p := range app.cfg.Profiles {
-		if p.Name == profileFlag {
+		if p.Name == name {
 			// Requested same profile; do nothing...
 			if currentProfile != nil && p.Name == currentProfile.Name {
 				return currentProfile, false, nil
@@ -52,203 +50,268 @@ func getOrUpdateCurrentProfile() (*configuration.Profile, bool, error) {
 		}
 	}
 
-	// If no profile specified and no previous configuration use the first profile available.
-	if len(profileFlag) < 1 {
+	// If no profile specified use the first profile available.
+	if len(name) < 1 {
+		if currentProfile != nil && currentProfile.Name == app.cfg.Profiles[0].Name {
+			return currentProfile, false, nil
+		}
+
 		currentProfile = &app.cfg.Profiles[0]
 		app.setCurrentProfile(0, currentProfile)
 		return currentProfile, true, nil
 	}
 
-	return nil, false, fmt.Errorf("unable to find profile (see 'snake profiles'): %s", profileFlag)
+	return nil, false, configurationError(fmt.Errorf("unable to find profile (see 'snake profiles'): %s", name))
 }
 
-func prettyPrintCMakeTraceResults() error {
-	file, err := os.Open(filepath.Join(app.db.ProfilePath, "cmake.trace"))
+// Options of a configuration.
+type ConfigureOptions struct {
+	// The profile to select. The first profile is used if empty.
+	Profile string
 
-	if err != nil {
-		return err
-	}
+	// Force installing the Snake modules and dependencies again.
+	Update bool
+
+	// Run CMake even if nothing changed since the last configuration.
+	Force bool
+
+	// Trace the CMake scripts and print the elapsed times.
+	Trace bool
+
+	// Also write the trace in the Chrome trace-event format to this path. Implies Trace.
+	TraceOut string
+
+	// Number of entries printed per trace report section (0 for all).
+	TraceTop int
 
-	defer file.Close()
+	// CMake cache variables (KEY=VALUE) overriding the profile options.
+	Variables []string
+}
+
+// Configure the build system of a profile.
+func (app *Application) Configure(opts ConfigureOptions) error {
+	defer app.timeTrack(time.Now(), "Configuration")
 
-	scanner := bufio.NewScanner(file)
+	fmt.Fprintln(app.OutOrStdout(), "Configuring...")
 
-	type TraceResult struct {
-		File  string   `json:"file"`
-		Line  int      `json:"line"`
-		Cmd   string   `json:"cmd"`
-		Args  []string `json:"args"`
-		Time  float64  `json:"time"`
-		Frame int      `json:"frame"`
+	err := app.initSlow()
+
+	if err != nil {
+		return err
 	}
 
-	var lastTime time.Time
-	durations := map[string]time.Duration{}
+	if len(opts.TraceOut) > 0 {
+		opts.Trace = true
+	}
 
-	i := 0
+	var cmakeOptions []string
 
-	for scanner.Scan() {
-		var trace TraceResult
+	// Restored if the configuration is interrupted.
+	previousStorage := app.db
 
-		if err = json.Unmarshal(scanner.Bytes(), &trace); err != nil {
-			return err
-		}
+	// Check if the configuration changed and if so regenerate.
 
-		if len(trace.Cmd) < 1 || trace.Time < 1 {
-			continue
-		}
+	currentProfile, profileChanged, err := app.getOrUpdateCurrentProfile(opts.Profile)
 
-		sec, dec := math.Modf(trace.Time)
-		t := time.Unix(int64(sec), int64(dec*(1e9)))
+	if err != nil {
+		return err
+	}
 
-		if i != 0 {
-			d := t.Sub(lastTime)
-			durations[trace.Cmd] = durations[trace.Cmd] + d
-		}
+	if err := validateSanitizers(currentProfile.Name, currentProfile.Sanitizers); err != nil {
+		return err
+	}
 
-		i++
-		lastTime = t
+	if err := app.runHooks("pre-configure"); err != nil {
+		return err
 	}
 
-	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+	cmakeOptions = append(cmakeOptions,
+		"-DSNAKE_DIR="+app.snakeDir,
+		"-B", app.db.ProfilePath, "-S", app.rootDir,
+		"-G", "Ninja",
+	)
 
-	for cmd, total := range durations {
-		fmt.Fprintln(writer, total.String()+"\t"+cmd)
+	if app.verbose {
+		cmakeOptions = append(cmakeOptions,
+			"--warn-uninitialized", "--warn-unused-vars", "--check-system-vars")
 	}
 
-	writer.Flush()
+	if opts.Trace {
+		cmakeOptions = append(cmakeOptions, "--trace-format=json-v1",
+			"--trace-redirect="+filepath.Join(app.db.ProfilePath, "cmake.trace"))
+	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+	if profileChanged {
+		if _, err := os.Stat(app.db.ProfilePath); os.IsNotExist(err) {
+			opts.Update = true
+		}
 	}
 
-	return nil
-}
+	out := app.OutOrStdout()
 
-var configureCmd = &cobra.Command{
-	Use:   "configure",
-	Short: "Configure the build system",
-	RunE: func(c *cobra.Command, args []string) error {
-		defer app.timeTrack(time.Now(), "Configuration")
+	fmt.Fprintln(out, "Configured:", app.db.Configured)
+	fmt.Fprintln(out, "Profile Changed:", profileChanged)
+	fmt.Fprintln(out, "Force Update:", opts.Update)
 
-		fmt.Println("Configuring...")
+	// Running CMake cannot be skipped after updating the modules.
+	updated := !app.db.Configured || opts.Update
 
-		err := app.initSlow()
+	if updated {
+		fmt.Fprintln(out, "Updating...")
 
-		if err != nil {
+		if err = app.decompress(); err != nil {
 			return err
 		}
 
-		if err != nil {
-			return err
+		if !(app.db.Configured && profileChanged) {
+			if err = os.RemoveAll(filepath.Join(app.snakeDir, "snake.lock")); err != nil {
+				return err
+			}
 		}
 
-		var cmakeOptions []string
+		app.db.Configured = true
+		app.storageChanged()
+	}
 
-		// Check if the configuration changed and if so regenerate.
+	fmt.Fprintln(out, "Load profile:", currentProfile.Name)
 
-		currentProfile, profileChanged, err := getOrUpdateCurrentProfile()
+	cmakeOptions = append(cmakeOptions, "-DSNAKE_PROFILE="+currentProfile.Name)
 
-		if err != nil {
-			return err
-		}
+	if len(currentProfile.Type) > 0 {
+		cmakeOptions = append(cmakeOptions,
+			"-DCMAKE_BUILD_TYPE="+currentProfile.Type)
+	}
 
+	if len(currentProfile.LinkFlags) > 0 {
 		cmakeOptions = append(cmakeOptions,
-			"-DSNAKE_DIR="+app.snakeDir,
-			"-B", app.db.ProfilePath, "-S", app.rootDir,
-			"-G", "Ninja",
-		)
-
-		if app.verbose {
-			cmakeOptions = append(cmakeOptions,
-				"--warn-uninitialized", "--warn-unused-vars", "--check-system-vars")
-		}
+			"-DSNAKE_GLOBAL_LINKER_OPTIONS="+strings.Join(
+				strings.Split(strings.Join(currentProfile.LinkFlags, " "), " "), ";"))
+	}
 
-		if traceFlag {
-			cmakeOptions = append(cmakeOptions, "--trace-format=json-v1",
-				"--trace-redirect="+filepath.Join(app.db.ProfilePath, "cmake.trace"))
-		}
+	if len(currentProfile.CompileFlags) > 0 {
+		cmakeOptions = append(cmakeOptions,
+			"-DSNAKE_GLOBAL_COMPILE_OPTIONS="+strings.Join(
+				strings.Split(strings.Join(currentProfile.CompileFlags, " "), " "), ";"))
+	}
 
-		if profileChanged {
-			if _, err := os.Stat(app.db.ProfilePath); os.IsNotExist(err) {
-				forceUpdateFlag = true
-			}
-		}
+	if len(currentProfile.Compiler) > 0 {
+		cmakeOptions = append(cmakeOptions,
+			"-DCMAKE_CXX_COMPILER="+currentProfile.Compiler)
+	}
 
-		fmt.Println("Configured:", app.db.Configured)
-		fmt.Println("Profile Changed:", profileChanged)
-		fmt.Println("Force Update:", forceUpdateFlag)
+	cmakeOptions = append(cmakeOptions,
+		instrumentationOptions(currentProfile.Sanitizers, currentProfile.Coverage)...)
 
-		if !app.db.Configured || forceUpdateFlag {
-			fmt.Println("Updating...")
+	for _, mapping := range currentProfile.Variables {
+		keys := []string{}
 
-			if err = app.decompress(); err != nil {
-				return err
-			}
+		for k := range mapping {
+			keys = append(keys, k)
+		}
 
-			if !(app.db.Configured && profileChanged) {
-				if err = os.RemoveAll(filepath.Join(app.snakeDir, "snake.lock")); err != nil {
-					return err
-				}
+		// Keep the arguments stable so the configuration can be skipped.
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			v := mapping[k]
+
+			if app.verbose {
+				fmt.Fprintln(out, "set:", k, v)
 			}
 
-			app.db.Configured = true
-			app.storageChanged()
+			cmakeOptions = append(cmakeOptions, fmt.Sprintf("-D%s=%s", k, v))
 		}
+	}
 
-		fmt.Println("Load profile:", currentProfile.Name)
+	for _, arg := range opts.Variables {
+		cmakeOptions = append(cmakeOptions, "-D"+arg)
+	}
 
-		if len(currentProfile.Type) > 0 {
-			cmakeOptions = append(cmakeOptions,
-				"-DCMAKE_BUILD_TYPE="+currentProfile.Type)
-		}
+	stamp := &configureStamp{Args: cmakeOptions, Inputs: app.configureInputs(currentProfile)}
 
-		if len(currentProfile.LinkFlags) > 0 {
-			cmakeOptions = append(cmakeOptions,
-				"-DSNAKE_GLOBAL_LINKER_OPTIONS="+strings.Join(
-					strings.Split(strings.Join(currentProfile.LinkFlags, " "), " "), ";"))
-		}
+	if !opts.Force && !opts.Trace && !updated && app.configurationUpToDate(stamp) {
+		fmt.Fprintln(out, "Configuration is up to date (use --force to run CMake anyway)")
 
-		if len(currentProfile.CompileFlags) > 0 {
-			cmakeOptions = append(cmakeOptions,
-				"-DSNAKE_GLOBAL_COMPILE_OPTIONS="+strings.Join(
-					strings.Split(strings.Join(currentProfile.CompileFlags, " "), " "), ";"))
+		if err := app.saveStorage(); err != nil {
+			return err
 		}
 
-		if len(currentProfile.Compiler) > 0 {
-			cmakeOptions = append(cmakeOptions,
-				"-DCMAKE_CXX_COMPILER="+currentProfile.Compiler)
-		}
+		// The pre-configure hooks already ran.
+		return app.runHooks("post-configure")
+	}
 
-		for _, mapping := range currentProfile.Variables {
-			for k, v := range mapping {
-				if app.verbose {
-					fmt.Println("set:", k, v)
-				}
+	// A failed configuration must not be skipped the next time.
+	if err := os.Remove(app.stampPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 
-				cmakeOptions = append(cmakeOptions, fmt.Sprintf("-D%s=%s", k, v))
-			}
+	if err := app.launch("cmake", cmakeOptions...); err != nil {
+		if app.context().Err() != nil {
+			app.discardConfiguration(previousStorage)
 		}
 
-		for _, arg := range args {
-			cmakeOptions = append(cmakeOptions, "-D"+arg)
-		}
+		return err
+	}
+
+	if err = app.writeStamp(stamp); err != nil {
+		return err
+	}
 
-		if err := app.launch("cmake", cmakeOptions...); err != nil {
+	if err = app.saveStorage(); err != nil {
+		return err
+	}
+
+	if err := app.runHooks("post-configure"); err != nil {
+		return err
+	}
+
+	if opts.Trace {
+		events, err := app.readTrace()
+
+		if err != nil {
 			return err
 		}
 
-		if err = app.saveStorage(); err != nil {
+		if err = app.printTraceReport(events, opts.TraceTop); err != nil {
 			return err
 		}
 
-		if traceFlag {
-			if err = prettyPrintCMakeTraceResults(); err != nil {
+		if len(opts.TraceOut) > 0 {
+			if err = app.writeChromeTrace(events, opts.TraceOut); err != nil {
 				return err
 			}
 		}
+	}
+
+	return nil
+}
+
+// Undo an interrupted configuration. CMake may have left a partially written cache
+// behind so the next configuration starts from scratch.
+func (app *Application) discardConfiguration(previous Storage) {
+	cache := filepath.Join(app.db.ProfilePath, "CMakeCache.txt")
+
+	if err := os.Remove(cache); err == nil {
+		fmt.Fprintln(app.ErrOrStderr(), "Configuration interrupted; removed", cache)
+	}
 
-		return nil
+	app.db = previous
+	app.storagePendingSave = false
+}
+
+var configureCmd = &cobra.Command{
+	Use:   "configure",
+	Short: "Configure the build system",
+	RunE: func(c *cobra.Command, args []string) error {
+		return app.Configure(ConfigureOptions{
+			Profile:   profileFlag,
+			Update:    forceUpdateFlag,
+			Force:     forceFlag,
+			Trace:     traceFlag,
+			TraceOut:  traceOutFlag,
+			TraceTop:  traceTopFlag,
+			Variables: args,
+		})
 	},
 }
 
@@ -257,9 +320,21 @@ func init() {
 		"update", false,
 		"Force download and install dependencies")
 
+	configureCmd.PersistentFlags().BoolVar(&forceFlag,
+		"force", false,
+		"Run CMake even if nothing changed since the last configuration")
+
 	configureCmd.PersistentFlags().BoolVar(&traceFlag,
 		"trace", false,
-		"Trace the CMake scripts and pretty print elapsed times")
+		"Trace the CMake scripts and report where the time is spent")
+
+	configureCmd.PersistentFlags().IntVar(&traceTopFlag,
+		"trace-top", 15,
+		"Number of entries printed per trace report section (0 for all)")
+
+	configureCmd.PersistentFlags().StringVar(&traceOutFlag,
+		"trace-out", "",
+		"Write the trace in the Chrome trace-event format (chrome://tracing, Perfetto)")
 
 	configureCmd.PersistentFlags().StringVarP(&profileFlag,
 		"profile", "p", "",
@@ -0,0 +1,323 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sumartian-studios/snake/coverage"
+)
+
+// Matches the version suffix of a compiler (ex. clang++-17 or g++-12).
+var compilerVersionRegex = regexp.MustCompile(`-(\d+(?:\.\d+)*)(?:\.exe)?$`)
+
+// Returns the compiler recorded in the CMake cache of the current profile.
+func (app *Application) cachedCompiler() string {
+	file, err := os.Open(filepath.Join(app.db.ProfilePath, "CMakeCache.txt"))
+
+	if err != nil {
+		return ""
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		if key, value, found := strings.Cut(scanner.Text(), "="); found &&
+			strings.HasPrefix(key, "CMAKE_CXX_COMPILER:") {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Returns the command producing .gcov files for the compiler of the current profile.
+// Clang uses llvm-cov and GCC uses gcov, preferring the tool matching the
+// compiler version.
+func (app *Application) gcovCommand() ([]string, error) {
+	compiler := filepath.Base(app.cachedCompiler())
+	candidates := [][]string{}
+	suffix := ""
+
+	if m := compilerVersionRegex.FindStringSubmatch(compiler); m != nil {
+		suffix = "-" + m[1]
+	}
+
+	if strings.Contains(compiler, "clang") {
+		if len(suffix) > 0 {
+			candidates = append(candidates, []string{"llvm-cov" + suffix, "gcov"})
+		}
+
+		candidates = append(candidates, []string{"llvm-cov", "gcov"})
+	} else {
+		if len(suffix) > 0 {
+			candidates = append(candidates, []string{"gcov" + suffix})
+		}
+
+		candidates = append(candidates, []string{"gcov"})
+	}
+
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to find %s (required by --coverage)", candidates[len(candidates)-1][0])
+}
+
+// Returns the coverage data files of the current profile by target.
+func (app *Application) coverageDataFiles() (map[string][]string, error) {
+	files := map[string][]string{}
+
+	err := filepath.WalkDir(app.db.ProfilePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || filepath.Ext(path) != ".gcda" {
+			return nil
+		}
+
+		if m := objectDirRegex.FindStringSubmatch(filepath.ToSlash(path)); m != nil {
+			files[m[1]] = append(files[m[1]], path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// Remove the coverage data of the previous runs.
+func (app *Application) resetCoverage() error {
+	files, err := app.coverageDataFiles()
+
+	if err != nil {
+		return err
+	}
+
+	for _, list := range files {
+		for _, file := range list {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns a path relative to the project if the file belongs to it. Files of the
+// build directory (ex. generated files and fetched dependencies) and files outside
+// of the project (ex. system headers) are excluded.
+func (app *Application) projectSource(file string) (string, bool) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(app.db.ProfilePath, file)
+	}
+
+	file = filepath.Clean(file)
+
+	for _, excluded := range []string{app.snakeDir, filepath.Join(app.rootDir, ".snake")} {
+		if rel, err := filepath.Rel(excluded, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return "", false
+		}
+	}
+
+	rel, err := filepath.Rel(app.rootDir, file)
+
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+
+	return rel, true
+}
+
+// Run gcov on the data files of a target and collect the coverage of the project sources.
+func (app *Application) targetCoverage(command []string, files []string) (*coverage.Report, error) {
+	dir, err := os.MkdirTemp("", "snake-coverage-")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+
+	// Headers are included by several translation units; long file names keep
+	// their .gcov files apart.
+	args := append(append([]string{}, command[1:]...), "--long-file-names", "--preserve-paths")
+
+	cmd := exec.CommandContext(app.context(), command[0], append(args, files...)...)
+	cmd.Dir = dir
+	cmd.Stdout = io.Discard
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", strings.Join(command, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := coverage.NewReport()
+
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) != ".gcov" {
+			continue
+		}
+
+		file, err := os.Open(filepath.Join(dir, e.Name()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		source, lines, err := coverage.ReadGcov(file)
+		file.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if rel, ok := app.projectSource(source); ok {
+			report.Add(rel, lines)
+		}
+	}
+
+	return report, nil
+}
+
+// Collect the coverage of the project sources by target.
+func (app *Application) collectCoverage() (map[string]*coverage.Report, error) {
+	command, err := app.gcovCommand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := app.coverageDataFiles()
+
+	if err != nil {
+		return nil, err
+	}
+
+	targets := map[string]*coverage.Report{}
+
+	for target, list := range files {
+		report, err := app.targetCoverage(command, list)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(report.Files) > 0 {
+			targets[target] = report
+		}
+	}
+
+	return targets, nil
+}
+
+// Write the coverage reports (lcov, Cobertura, and HTML) of the current profile
+// and print the coverage of each target.
+func (app *Application) reportCoverage(profile string) error {
+	targets, err := app.collectCoverage()
+
+	if err != nil {
+		return err
+	}
+
+	out := app.OutOrStdout()
+
+	if len(targets) == 0 {
+		fmt.Fprintln(out, "No coverage data found (did the tests run?)")
+		return nil
+	}
+
+	dir := filepath.Join(app.db.ProfilePath, "coverage")
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	total := coverage.NewReport()
+
+	for _, r := range targets {
+		total.Merge(r)
+	}
+
+	reports := []struct {
+		name  string
+		write func(w io.Writer) error
+	}{
+		{"coverage.info", func(w io.Writer) error { return total.WriteLcov(w, profile, app.rootDir) }},
+		{"coverage.xml", func(w io.Writer) error { return coverage.WriteCobertura(w, targets, app.rootDir) }},
+		{"index.html", func(w io.Writer) error {
+			return coverage.WriteHTML(w, targets, app.rootDir, app.cfg.Project+" coverage ("+profile+")")
+		}},
+	}
+
+	for _, r := range reports {
+		file, err := os.Create(filepath.Join(dir, r.name))
+
+		if err != nil {
+			return err
+		}
+
+		if err = r.write(file); err != nil {
+			file.Close()
+			return err
+		}
+
+		if err = file.Close(); err != nil {
+			return err
+		}
+	}
+
+	names := []string{}
+
+	for name := range targets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	writer := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "\nTARGET\tLINES\tCOVERED")
+
+	for _, name := range names {
+		c, v := targets[name].Counts()
+		fmt.Fprintf(writer, "%s\t%.1f%%\t%d/%d\n", name, 100*coverage.Rate(c, v), c, v)
+	}
+
+	c, v := total.Counts()
+	fmt.Fprintf(writer, "total\t%.1f%%\t%d/%d\n", 100*coverage.Rate(c, v), c, v)
+
+	writer.Flush()
+
+	fmt.Fprintln(out, "\nCoverage reports written to", dir)
+
+	return nil
+}
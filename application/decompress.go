@@ -10,12 +10,17 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/sumartian-studios/snake/distribution"
 	"github.com/sumartian-studios/snake/utilities"
 )
 
 // Decompress the embedded zip file.
 func (app *Application) decompress() error {
-	file, err := app.dataZip.ReadFile("distribution/" + VersionStr + ".zip")
+	file, err := distribution.Archives.ReadFile(VersionStr + ".zip")
+
+	if err != nil {
+		return fmt.Errorf("unable to find embedded zip: %w", err)
+	}
 
 	reader := bytes.NewReader(file)
 	zipReader, err := zip.NewReader(reader, int64(len(file)))
@@ -0,0 +1,303 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+	"github.com/sumartian-studios/snake/diagnostics"
+)
+
+// Number of errors printed at the end of a build. The 'errors' command prints all of them.
+const summaryErrorLimit = 20
+
+// Path to the diagnostics of the last build of the current profile.
+func (app *Application) diagnosticsPath() string {
+	return filepath.Join(app.db.ProfilePath, "snake.diagnostics.json")
+}
+
+// Returns true if the writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && readline.IsTerminal(int(f.Fd()))
+}
+
+// Launch a build tool and parse the compiler diagnostics in its output. Ninja
+// prints the output of the compilers on its standard output.
+func (app *Application) launchCollecting(program string, args ...string) ([]diagnostics.Diagnostic, error) {
+	collector := diagnostics.NewCollector()
+	out := app.OutOrStdout()
+	env := os.Environ()
+
+	// Ninja strips the colors when its output is not a terminal.
+	if isTerminal(out) {
+		env = append(env, "CLICOLOR_FORCE=1")
+	}
+
+	err := app.launchWith(io.MultiWriter(out, collector), env, program, args...)
+
+	return app.resolveDiagnostics(collector.Diagnostics()), err
+}
+
+// Returns the target containing a source file or an empty string.
+func (app *Application) fileTarget(file string) string {
+	if app.cfg.Targets == nil {
+		return ""
+	}
+
+	best := ""
+	length := -1
+
+	for _, t := range *app.cfg.Targets {
+		dir := filepath.Clean(t.Path) + string(filepath.Separator)
+
+		// A target at the root of the project contains every file unless a
+		// nested target does.
+		if dir == "."+string(filepath.Separator) {
+			dir = ""
+		}
+
+		if strings.HasPrefix(file, dir) && len(dir) > length {
+			best, length = t.Name, len(dir)
+		}
+	}
+
+	return best
+}
+
+// Make the paths relative to the project and find the targets being built.
+func (app *Application) resolveDiagnostics(list []diagnostics.Diagnostic) []diagnostics.Diagnostic {
+	for i := range list {
+		d := &list[i]
+
+		// Ninja runs the compilers from the build directory.
+		if len(d.File) > 0 && d.Line > 0 && !filepath.IsAbs(d.File) {
+			d.File = filepath.Join(app.db.ProfilePath, d.File)
+		}
+
+		if filepath.IsAbs(d.File) {
+			d.File = app.shortPath(d.File)
+		}
+
+		if len(d.Output) > 0 {
+			d.Target = app.outputTarget(d.Output)
+		}
+
+		if len(d.Target) == 0 {
+			d.Target = app.fileTarget(d.File)
+		}
+	}
+
+	return list
+}
+
+// Save the diagnostics of the last build for the 'errors' command.
+func (app *Application) saveDiagnostics(list []diagnostics.Diagnostic) error {
+	data, err := json.MarshalIndent(list, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(app.diagnosticsPath(), data, 0666)
+}
+
+// Load the diagnostics of the last build.
+func (app *Application) loadDiagnostics() ([]diagnostics.Diagnostic, error) {
+	data, err := os.ReadFile(app.diagnosticsPath())
+
+	if os.IsNotExist(err) {
+		return nil, configurationError(errors.New("no build diagnostics found (see 'snake build')"))
+	} else if err != nil {
+		return nil, err
+	}
+
+	list := []diagnostics.Diagnostic{}
+
+	return list, json.Unmarshal(data, &list)
+}
+
+// Write the diagnostics to a file. SARIF is used if the file name contains
+// ".sarif" and JSON otherwise.
+func (app *Application) writeDiagnostics(list []diagnostics.Diagnostic, path string) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(filepath.Base(path), ".sarif") {
+		err = diagnostics.WriteSARIF(file, list)
+	} else {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(list)
+	}
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Print the errors and the warnings grouped by target and flag. Only the first
+// errors are printed unless limit is 0.
+func (app *Application) printDiagnosticsSummary(list []diagnostics.Diagnostic, limit int) {
+	out := app.OutOrStdout()
+	errorCount, warningCount := diagnostics.Count(list)
+
+	fmt.Fprintf(out, "\nBuild diagnostics: %d errors, %d warnings\n", errorCount, warningCount)
+
+	if len(list) == 0 {
+		return
+	}
+
+	if errorCount > 0 {
+		fmt.Fprintln(out, "\nErrors:")
+
+		printed := 0
+
+		for _, d := range list {
+			if d.Severity != "error" {
+				continue
+			}
+
+			if limit > 0 && printed == limit {
+				fmt.Fprintf(out, "... %d more (see 'snake errors')\n", errorCount-printed)
+				break
+			}
+
+			location := d.File
+
+			if d.Line > 0 {
+				location = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+			}
+
+			if len(d.Target) > 0 {
+				fmt.Fprintf(out, "%s: %s (%s)\n", location, d.Message, d.Target)
+			} else {
+				fmt.Fprintf(out, "%s: %s\n", location, d.Message)
+			}
+
+			printed++
+		}
+	}
+
+	type counts struct{ errors, warnings int }
+
+	targets := map[string]*counts{}
+	flags := map[string]int{}
+
+	for _, d := range list {
+		target := d.Target
+
+		if len(target) == 0 {
+			target = "(other)"
+		}
+
+		if targets[target] == nil {
+			targets[target] = &counts{}
+		}
+
+		if d.Severity == "error" {
+			targets[target].errors++
+		} else {
+			targets[target].warnings++
+
+			if len(d.Flag) > 0 {
+				flags[d.Flag]++
+			} else {
+				flags["(no flag)"]++
+			}
+		}
+	}
+
+	names := []string{}
+
+	for name := range targets {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := targets[names[i]], targets[names[j]]
+
+		if a.errors != b.errors {
+			return a.errors > b.errors
+		} else if a.warnings != b.warnings {
+			return a.warnings > b.warnings
+		}
+
+		return names[i] < names[j]
+	})
+
+	writer := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "\nTARGET\tERRORS\tWARNINGS")
+
+	for _, name := range names {
+		fmt.Fprintf(writer, "%s\t%d\t%d\n", name, targets[name].errors, targets[name].warnings)
+	}
+
+	if len(flags) > 0 {
+		names = []string{}
+
+		for flag := range flags {
+			names = append(names, flag)
+		}
+
+		sort.Slice(names, func(i, j int) bool {
+			if flags[names[i]] != flags[names[j]] {
+				return flags[names[i]] > flags[names[j]]
+			}
+
+			return names[i] < names[j]
+		})
+
+		fmt.Fprintln(writer, "\nFLAG\tWARNINGS")
+
+		for _, flag := range names {
+			fmt.Fprintf(writer, "%s\t%d\n", flag, flags[flag])
+		}
+	}
+
+	writer.Flush()
+}
+
+var errorsCmd = &cobra.Command{
+	Use:   "errors",
+	Short: "Show the diagnostics summary of the last build",
+	RunE: func(c *cobra.Command, args []string) error {
+		if err := app.initSlow(); err != nil {
+			return err
+		}
+
+		if app.db.ProfileIndex == -1 || len(app.db.ProfilePath) == 0 {
+			return configurationError(errors.New("you must re-configure this project (snake configure)"))
+		}
+
+		list, err := app.loadDiagnostics()
+
+		if err != nil {
+			return err
+		}
+
+		app.printDiagnosticsSummary(list, 0)
+
+		return nil
+	},
+}
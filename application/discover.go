@@ -0,0 +1,208 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sumartian-studios/snake/configuration"
+	"github.com/sumartian-studios/snake/discover"
+)
+
+// Directory of the files used by the test discovery. The path of each test binary
+// is written there by snake_discover_tests and CTest includes the discovered tests
+// from there.
+func (app *Application) discoveryDir() string {
+	return filepath.Join(app.db.ProfilePath, "snake.discover")
+}
+
+// DiscoveredTests are the test cases listed by a test binary.
+type DiscoveredTests struct {
+	// The framework used to list the test cases.
+	Framework string `json:"Framework"`
+
+	// The names of the test cases.
+	Cases []string `json:"Cases"`
+}
+
+// Returns the CTest name of a discovered test case.
+func discoveredTestName(target string, name string) string {
+	return target + "/" + name
+}
+
+// Returns a CMake bracket argument so the value does not need escaping.
+func bracketArgument(s string) string {
+	equals := ""
+
+	for strings.Contains(s+"]", "]"+equals+"]") {
+		equals += "="
+	}
+
+	return "[" + equals + "[" + s + "]" + equals + "]"
+}
+
+// List the test cases of a test binary and write the CTest script registering them.
+func (app *Application) discoverTargetTests(t *configuration.Target, binary string, script string) ([]string, error) {
+	framework, found := discover.Frameworks[t.Discover]
+
+	// The configuration may have changed since it was validated.
+	if !found {
+		return nil, configurationError(fmt.Errorf("invalid test discovery of %s (%s): %s",
+			t.Name, strings.Join(discover.Names(), ", "), t.Discover))
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(app.context(), binary, framework.List...)
+	cmd.Dir = filepath.Dir(binary)
+	cmd.Env = os.Environ()
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// QTEST_MAIN creates the application before listing the functions.
+	if t.Discover == "qttest" && len(os.Getenv("QT_QPA_PLATFORM")) == 0 {
+		cmd.Env = append(cmd.Env, "QT_QPA_PLATFORM=offscreen")
+	}
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s %s: %w: %s", binary, strings.Join(framework.List, " "), err,
+			strings.TrimSpace(stderr.String()))
+	}
+
+	cases := framework.Parse(stdout.String())
+
+	var b bytes.Buffer
+
+	b.WriteString("# Generated by Snake after building " + t.Name + ". Do not edit.\n")
+
+	for _, c := range cases {
+		args := []string{bracketArgument(discoveredTestName(t.Name, c)), bracketArgument(binary)}
+
+		for _, a := range framework.Run(c) {
+			args = append(args, bracketArgument(a))
+		}
+
+		fmt.Fprintf(&b, "add_test(%s)\n", strings.Join(args, " "))
+	}
+
+	return cases, os.WriteFile(script, b.Bytes(), 0644)
+}
+
+// Discover the test cases of the test targets using their framework and register
+// them with CTest. Binaries are only listed again after being rebuilt or when
+// their framework changes and targets that were not built are skipped. Binaries
+// that cannot be listed (ex. cross-compiled) keep their previous test cases.
+func (app *Application) discoverTests() error {
+	exists, profile := app.getCurrentProfile()
+
+	if !exists || app.cfg.Targets == nil {
+		return nil
+	}
+
+	previous := app.db.Discovered[profile.Name]
+	discovered := map[string]DiscoveredTests{}
+
+	for _, t := range *app.cfg.Targets {
+		if t.Type != "test" || len(t.Discover) == 0 {
+			continue
+		}
+
+		base := filepath.Join(app.discoveryDir(), t.Name)
+		data, err := os.ReadFile(base + ".path")
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		binary := strings.TrimSpace(string(data))
+		info, err := os.Stat(binary)
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		tests, found := previous[t.Name]
+		s, err := os.Stat(base + ".cmake")
+
+		if !found || err != nil || tests.Framework != t.Discover || s.ModTime().Before(info.ModTime()) {
+			cases, err := app.discoverTargetTests(&t, binary, base+".cmake")
+
+			var exitErr *ExitError
+
+			if errors.As(err, &exitErr) {
+				return err
+			} else if err != nil {
+				fmt.Fprintf(app.ErrOrStderr(), "warning: unable to discover the tests of %s: %v\n", t.Name, err)
+
+				if found {
+					discovered[t.Name] = tests
+				}
+
+				continue
+			}
+
+			tests = DiscoveredTests{Framework: t.Discover, Cases: cases}
+
+			if app.verbose {
+				fmt.Fprintf(app.OutOrStdout(), "Discovered %d tests in %s\n", len(cases), t.Name)
+			}
+		}
+
+		discovered[t.Name] = tests
+	}
+
+	if app.db.Discovered == nil {
+		app.db.Discovered = map[string]map[string]DiscoveredTests{}
+	}
+
+	app.db.Discovered[profile.Name] = discovered
+	app.storageChanged()
+
+	return app.saveStorage()
+}
+
+// Returns the CTest names of the discovered test cases by target for the
+// current profile.
+func (app *Application) discoveredTests() map[string][]string {
+	tests := map[string][]string{}
+	exists, profile := app.getCurrentProfile()
+
+	if !exists {
+		return tests
+	}
+
+	for target, discovered := range app.db.Discovered[profile.Name] {
+		for _, c := range discovered.Cases {
+			tests[target] = append(tests[target], discoveredTestName(target, c))
+		}
+	}
+
+	return tests
+}
+
+// Returns the CTest names of the discovered test cases in increasing order.
+func (app *Application) discoveredTestNames() []string {
+	names := []string{}
+
+	for _, list := range app.discoveredTests() {
+		names = append(names, list...)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// Exit codes returned by the Snake executable. Subprocess failures (cmake, ninja,
+// ctest and user programs) forward the exit code of the child instead; a child
+// terminated by a signal exits with 128 plus the signal number like a shell would.
+const (
+	// The command completed successfully.
+	ExitSuccess = 0
+
+	// A tool or subprocess failed without providing an exit code.
+	ExitFailure = 1
+
+	// The command-line arguments or flags are invalid.
+	ExitUsage = 2
+
+	// The .snake.yml file, a profile, or the Snake storage is invalid or missing.
+	ExitConfiguration = 3
+)
+
+// ExitError is an error associated with a process exit code.
+type ExitError struct {
+	// The process exit code.
+	Code int
+
+	// The underlying error.
+	Err error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Returns the exit code associated with an error.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var exitErr *ExitError
+
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitFailure
+}
+
+// Wraps an error caused by an invalid configuration.
+func configurationError(err error) error {
+	return &ExitError{Code: ExitConfiguration, Err: err}
+}
+
+// Wraps an error caused by invalid command-line arguments.
+func usageError(err error) error {
+	return &ExitError{Code: ExitUsage, Err: err}
+}
+
+// Converts the error returned by a subprocess into an error carrying its exit code.
+func subprocessError(program string, err error) error {
+	var exitErr *exec.ExitError
+
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("%s: %w", filepath.Base(program), err)
+	}
+
+	name := filepath.Base(program)
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return &ExitError{
+			Code: 128 + int(status.Signal()),
+			Err:  fmt.Errorf("%s terminated by signal: %v", name, status.Signal()),
+		}
+	}
+
+	return &ExitError{
+		Code: exitErr.ExitCode(),
+		Err:  fmt.Errorf("%s exited with code %d", name, exitErr.ExitCode()),
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Number of results kept per test and profile.
+const testHistorySize = 30
+
+// TestRecord is the result of a test in a run of 'snake test'.
+type TestRecord struct {
+	// Unix time of the run.
+	Time int64 `json:"Time"`
+
+	// One of "passed", "failed", or "skipped".
+	Status string `json:"Status"`
+
+	// Number of times the test was run (more than 1 if it was retried).
+	Attempts int `json:"Attempts"`
+
+	// Duration of the last attempt (in seconds).
+	Duration float64 `json:"Duration"`
+}
+
+// Returns true if the test is quarantined. Entries of the quarantine are test
+// names, test groups, or targets with discovered tests.
+func (app *Application) quarantined(name string) bool {
+	for _, q := range app.cfg.Quarantine {
+		if name == q || strings.HasPrefix(name, q+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Record the result of each test in the history of the profile.
+func (app *Application) recordTestResults(profile string, result *TestRunResult) {
+	if app.db.Tests == nil {
+		app.db.Tests = map[string]map[string][]TestRecord{}
+	}
+
+	tests := app.db.Tests[profile]
+
+	if tests == nil {
+		tests = map[string][]TestRecord{}
+		app.db.Tests[profile] = tests
+	}
+
+	now := time.Now().Unix()
+
+	for _, t := range result.Tests {
+		history := append(tests[t.Name], TestRecord{
+			Time:     now,
+			Status:   t.Status,
+			Attempts: t.Attempts,
+			Duration: t.Duration,
+		})
+
+		if len(history) > testHistorySize {
+			history = history[len(history)-testHistorySize:]
+		}
+
+		tests[t.Name] = history
+	}
+
+	app.storageChanged()
+}
+
+// The summary of the history of a test with intermittent results.
+type flakyTestInfo struct {
+	Name          string
+	Runs          int
+	Passed        int
+	PassedOnRetry int
+	Failed        int
+	Last          string
+	Quarantined   bool
+}
+
+// Returns the tests of the current profile that failed and passed across runs or
+// that only passed on retry. The least reliable tests come first.
+func (app *Application) flakyTests(profile string) []flakyTestInfo {
+	list := []flakyTestInfo{}
+
+	for name, history := range app.db.Tests[profile] {
+		info := flakyTestInfo{Name: name, Quarantined: app.quarantined(name)}
+
+		for _, r := range history {
+			switch {
+			case r.Status == "failed":
+				info.Failed++
+			case r.Status == "passed" && r.Attempts > 1:
+				info.PassedOnRetry++
+			case r.Status == "passed":
+				info.Passed++
+			default:
+				continue
+			}
+
+			info.Runs++
+			info.Last = r.Status
+
+			if r.Status == "passed" && r.Attempts > 1 {
+				info.Last = "passed on retry"
+			}
+		}
+
+		if info.PassedOnRetry > 0 || (info.Passed > 0 && info.Failed > 0) {
+			list = append(list, info)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].Failed+list[i].PassedOnRetry, list[j].Failed+list[j].PassedOnRetry
+
+		if a != b {
+			return a > b
+		}
+
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// Print the tests of the current profile with intermittent results.
+func (app *Application) printFlakyReport() error {
+	exists, profile := app.getCurrentProfile()
+
+	if !exists {
+		return configurationError(errors.New("you must re-configure this project (snake configure)"))
+	}
+
+	out := app.OutOrStdout()
+	list := app.flakyTests(profile.Name)
+
+	if len(list) == 0 {
+		fmt.Fprintf(out, "No flaky tests recorded for %s (last %d runs of each test)\n", profile.Name, testHistorySize)
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "TEST\tRUNS\tPASSED\tPASSED ON RETRY\tFAILED\tLAST\tQUARANTINED")
+
+	for _, t := range list {
+		quarantined := "no"
+
+		if t.Quarantined {
+			quarantined = "yes"
+		}
+
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			t.Name, t.Runs, t.Passed, t.PassedOnRetry, t.Failed, t.Last, quarantined)
+	}
+
+	return writer.Flush()
+}
+
+// Print the tests passing on retry and the quarantined failures. Returns the error
+// of CTest unless only quarantined tests failed.
+func (app *Application) checkTestResults(result *TestRunResult, testErr error) error {
+	retried, quarantined, failed := []string{}, []string{}, 0
+
+	for _, t := range result.Tests {
+		switch {
+		case t.Status == "passed" && t.Attempts > 1:
+			retried = append(retried, t.Name)
+		case t.Status == "failed" && t.Quarantined:
+			quarantined = append(quarantined, t.Name)
+		case t.Status == "failed":
+			failed++
+		}
+	}
+
+	out := app.OutOrStdout()
+
+	if len(retried) > 0 {
+		fmt.Fprintln(out, "\nPassed on retry:", strings.Join(retried, ", "))
+	}
+
+	if len(quarantined) > 0 {
+		fmt.Fprintln(out, "\nQuarantined tests failed (ignored):", strings.Join(quarantined, ", "))
+
+		if failed == 0 {
+			return nil
+		}
+	}
+
+	return testErr
+}
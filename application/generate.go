@@ -13,173 +13,284 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/sumartian-studios/snake/cmake"
+	"github.com/sumartian-studios/snake/configuration"
+	"github.com/sumartian-studios/snake/discover"
 )
 
-var generateCmd = &cobra.Command{
-	Use:   "generate",
-	Short: "Re-generate the CMakeLists.txt",
-	RunE: func(c *cobra.Command, args []string) error {
-		defer app.timeTrack(time.Now(), "Generation")
+// Generate the CMakeLists.txt from the configuration.
+func (app *Application) Generate() error {
+	defer app.timeTrack(time.Now(), "Generation")
 
-		err := app.initSlow()
+	if err := app.initSlow(); err != nil {
+		return err
+	}
 
-		if err != nil {
-			return err
-		}
-
-		cmakeListsTxt := filepath.Join(app.rootDir, "CMakeLists.txt")
+	cmakeListsTxt := filepath.Join(app.rootDir, "CMakeLists.txt")
 
-		fmt.Println("Generating...", cmakeListsTxt)
+	fmt.Fprintln(app.OutOrStdout(), "Generating...", cmakeListsTxt)
 
-		g := new(cmake.Generator)
+	g := new(cmake.Generator)
 
-		g.Context.IfAliasMap = map[string]bool{
-			"and":      true,
-			"exists":   true,
-			"or":       true,
-			"not":      true,
-			"strequal": true,
-			"less":     true,
-			"greater":  true,
-			"matches":  true,
-		}
+	g.Context.IfAliasMap = map[string]bool{
+		"and":      true,
+		"exists":   true,
+		"or":       true,
+		"not":      true,
+		"strequal": true,
+		"less":     true,
+		"greater":  true,
+		"matches":  true,
+	}
 
-		g.Buffer = &g.Start
+	g.Buffer = &g.Start
 
-		g.Buffer.WriteString(fmt.Sprintf("# Generated by Snake (%s). You must not modify this file.\n\n", app.Version))
+	g.Buffer.WriteString(fmt.Sprintf("# Generated by Snake (%s). You must not modify this file.\n\n", VersionStr))
 
-		g.Call("if", "NOT", "DEFINED", "SNAKE_DIR")
-		g.Call("message", "STATUS", cmake.Quote("Snake directory is not defined..."))
-		g.Call("if", "DEFINED", "NO_SNAKE")
-		g.Call("set", "SNAKE_DIR", cmake.Quote("${CMAKE_BINARY_DIR}"), "CACHE", "INTERNAL", cmake.Quote(""))
-		g.Call("message", "STATUS", cmake.Quote("Not using Snake... ${SNAKE_DIR}"))
-		g.Call("else")
-		g.Call("message", "FATAL_ERROR", cmake.Quote("You must re-configure the project using Snake or set NO_SNAKE=on"))
-		g.Call("endif")
-		g.Call("else")
-		g.Call("message", "STATUS", cmake.Quote("Slithering into... ${SNAKE_DIR}"))
-		g.Call("endif")
+	g.Call("if", "NOT", "DEFINED", "SNAKE_DIR")
+	g.Call("message", "STATUS", cmake.Quote("Snake directory is not defined..."))
+	g.Call("if", "DEFINED", "NO_SNAKE")
+	g.Call("set", "SNAKE_DIR", cmake.Quote("${CMAKE_BINARY_DIR}"), "CACHE", "INTERNAL", cmake.Quote(""))
+	g.Call("message", "STATUS", cmake.Quote("Not using Snake... ${SNAKE_DIR}"))
+	g.Call("else")
+	g.Call("message", "FATAL_ERROR", cmake.Quote("You must re-configure the project using Snake or set NO_SNAKE=on"))
+	g.Call("endif")
+	g.Call("else")
+	g.Call("message", "STATUS", cmake.Quote("Slithering into... ${SNAKE_DIR}"))
+	g.Call("endif")
 
-		g.Call("cmake_minimum_required", "VERSION", "3.30.0", "FATAL_ERROR")
-		g.Call("project", app.cfg.Project, "VERSION", app.cfg.Version, "LANGUAGES", "CXX")
+	g.Call("cmake_minimum_required", "VERSION", "3.30.0", "FATAL_ERROR")
+	g.Call("project", app.cfg.Project, "VERSION", app.cfg.Version, "LANGUAGES", "CXX")
 
-		g.Call("set", "SNAKE_CONTACT", cmake.Quote(app.cfg.Contact))
-		g.Call("set", "SNAKE_ORGANIZATION", cmake.Quote(app.cfg.Organization))
-		g.Call("set", "SNAKE_PROJECT_LICENSE", cmake.Quote(app.cfg.License))
-		g.Call("set", "SNAKE_PROJECT_REPOSITORY", cmake.Quote(app.cfg.Repository))
+	g.Call("set", "SNAKE_CONTACT", cmake.Quote(app.cfg.Contact))
+	g.Call("set", "SNAKE_ORGANIZATION", cmake.Quote(app.cfg.Organization))
+	g.Call("set", "SNAKE_PROJECT_LICENSE", cmake.Quote(app.cfg.License))
+	g.Call("set", "SNAKE_PROJECT_REPOSITORY", cmake.Quote(app.cfg.Repository))
 
-		g.Call("set", "CMAKE_PROJECT_HOMEPAGE_URL", cmake.Quote(app.cfg.Site))
-		g.Call("set", "CMAKE_PROJECT_DESCRIPTION", cmake.Quote(app.cfg.Description))
+	g.Call("set", "CMAKE_PROJECT_HOMEPAGE_URL", cmake.Quote(app.cfg.Site))
+	g.Call("set", "CMAKE_PROJECT_DESCRIPTION", cmake.Quote(app.cfg.Description))
 
-		g.Call("include", cmake.Quote("${SNAKE_DIR}/snake.1.cmake"))
-		g.Call("include", cmake.Quote("${SNAKE_DIR}/snake.2.cmake"))
+	g.Call("include", cmake.Quote("${SNAKE_DIR}/snake.1.cmake"))
+	g.Call("include", cmake.Quote("${SNAKE_DIR}/snake.2.cmake"))
 
-		g.Context.LibraryMap = map[string]cmake.PreDependency{}
-		g.Context.RequirementMap = map[string]map[string]bool{}
+	g.Context.LibraryMap = map[string]cmake.PreDependency{}
+	g.Context.RequirementMap = map[string]map[string]bool{}
 
-		if app.cfg.Dependencies != nil {
-			dependencies := *app.cfg.Dependencies
+	if app.cfg.Dependencies != nil {
+		dependencies := *app.cfg.Dependencies
 
-			for i, d := range dependencies {
-				before, _, _ := strings.Cut(d.Package, "/")
+		for i, d := range dependencies {
+			before, _, _ := strings.Cut(d.Package, "/")
 
-				if len(before) < 1 {
-					return fmt.Errorf("package name cannot be empty: %s", d.Package)
-				}
+			if len(before) < 1 {
+				return configurationError(fmt.Errorf("package name cannot be empty: %s", d.Package))
+			}
 
-				for _, imports := range d.Imports {
-					g.Context.LibraryMap[imports.Name] = cmake.PreDependency{
-						FindPackageString: imports.Declare,
-						Dependency:        &dependencies[i],
-					}
+			for _, imports := range d.Imports {
+				g.Context.LibraryMap[imports.Name] = cmake.PreDependency{
+					FindPackageString: imports.Declare,
+					Dependency:        &dependencies[i],
 				}
 			}
 		}
+	}
 
-		if app.cfg.Features != nil {
-			feats := *app.cfg.Features
+	if app.cfg.Features != nil {
+		feats := *app.cfg.Features
 
-			for _, feat := range feats {
-				g.AddGlobalFeature(&feat)
-			}
+		for _, feat := range feats {
+			g.AddGlobalFeature(&feat)
 		}
+	}
 
-		// The end buffer starts here. Append to g.Start to preprend to g.End.
-		g.Buffer = &g.End
+	g.Context.Warnings = app.cfg.Warnings
 
-		g.Call("include", cmake.Quote("${SNAKE_DIR}/snake.3.cmake"))
+	if err := app.validateWarnings(); err != nil {
+		return err
+	}
 
-		if app.cfg.Targets != nil {
-			targets := *app.cfg.Targets
-			count := len(targets)
+	if err := app.validateTests(); err != nil {
+		return err
+	}
 
-			for i, t := range targets {
-				g.AddTarget(&t, i, count)
-			}
+	if err := app.validateScripts(); err != nil {
+		return err
+	}
+
+	// The end buffer starts here. Append to g.Start to preprend to g.End.
+	g.Buffer = &g.End
+
+	g.Call("include", cmake.Quote("${SNAKE_DIR}/snake.3.cmake"))
+
+	if app.cfg.Targets != nil {
+		targets := *app.cfg.Targets
+		count := len(targets)
+
+		for i, t := range targets {
+			g.AddTarget(&t, i, count)
 		}
+	}
 
-		g.Buffer = &g.Start
+	g.Buffer = &g.Start
 
-		for k, v := range g.Context.RequirementMap {
-			requirements := []string{}
+	for k, v := range g.Context.RequirementMap {
+		requirements := []string{}
 
-			for kk := range v {
-				requirements = append(requirements, "("+kk+")")
-			}
+		for kk := range v {
+			requirements = append(requirements, "("+kk+")")
+		}
 
-			if l := g.Context.LibraryMap[k]; l.Dependency != nil {
+		if l := g.Context.LibraryMap[k]; l.Dependency != nil {
+
+			if l.Dependency.From == "pkg" {
+				g.Call("if", g.CleanConditional(strings.Join(requirements, " AND ")))
+				g.Call("snake_fetch_pkg", cmake.Quote(l.Dependency.Package))
+				g.Call("endif")
+			} else if l.Dependency.From == "conan" {
+				g.Call("if", g.CleanConditional(strings.Join(requirements, " AND ")))
+				g.Call("list", "APPEND", "ENABLED_CONAN_PACKAGES", cmake.Quote(l.Dependency.Package))
+				g.Call("endif")
+			} else if l.Dependency.From == "url" || l.Dependency.From == "git" {
+				before, after, found := strings.Cut(l.Dependency.Package, "/")
+
+				if !found {
+					return configurationError(fmt.Errorf("invalid arguments: %s", l.Dependency.Package))
+				}
 
-				if l.Dependency.From == "pkg" {
+				if l.Dependency.From == "url" {
 					g.Call("if", g.CleanConditional(strings.Join(requirements, " AND ")))
-					g.Call("snake_fetch_pkg", cmake.Quote(l.Dependency.Package))
+					g.Call("snake_fetch_url", cmake.Quote(before),
+						cmake.Quote(l.Dependency.Path), cmake.Quote(after))
 					g.Call("endif")
-				} else if l.Dependency.From == "conan" {
+				} else {
 					g.Call("if", g.CleanConditional(strings.Join(requirements, " AND ")))
-					g.Call("list", "APPEND", "ENABLED_CONAN_PACKAGES", cmake.Quote(l.Dependency.Package))
+					g.Call("snake_fetch_git", cmake.Quote(before),
+						cmake.Quote(l.Dependency.Path), cmake.Quote(after))
 					g.Call("endif")
-				} else if l.Dependency.From == "url" || l.Dependency.From == "git" {
-					before, after, found := strings.Cut(l.Dependency.Package, "/")
-
-					if !found {
-						return fmt.Errorf("invalid arguments: %s", l.Dependency.Package)
-					}
-
-					if l.Dependency.From == "url" {
-						g.Call("if", g.CleanConditional(strings.Join(requirements, " AND ")))
-						g.Call("snake_fetch_url", cmake.Quote(before),
-							cmake.Quote(l.Dependency.Path), cmake.Quote(after))
-						g.Call("endif")
-					} else {
-						g.Call("if", g.CleanConditional(strings.Join(requirements, " AND ")))
-						g.Call("snake_fetch_git", cmake.Quote(before),
-							cmake.Quote(l.Dependency.Path), cmake.Quote(after))
-						g.Call("endif")
-					}
-				} else if l.Dependency.From == "system" {
-					// Nothing to do...
-				} else {
-					return fmt.Errorf("unsupported dependency provider: %s", l.Dependency.From)
 				}
+			} else if l.Dependency.From == "system" {
+				// Nothing to do...
+			} else {
+				return configurationError(fmt.Errorf("unsupported dependency provider: %s", l.Dependency.From))
+			}
+
+		}
+	}
 
+	g.Buffer = &g.End
+
+	// Scripts
+	if app.cfg.Scripts != nil {
+		scripts := *app.cfg.Scripts
+
+		g.Context.TargetMap = map[string]bool{}
+
+		for _, s := range scripts {
+			g.Context.TargetMap[s.Name] = true
+		}
+
+		if app.cfg.Targets != nil {
+			for _, t := range *app.cfg.Targets {
+				g.Context.TargetMap[t.Name] = true
 			}
 		}
 
-		g.Buffer = &g.End
+		for _, s := range scripts {
+			g.AddScript(&s)
+		}
+	}
+
+	g.Call("include", cmake.Quote("${SNAKE_DIR}/snake.4.cmake"))
+
+	if err := g.Save(cmakeListsTxt); err != nil {
+		return err
+	}
+
+	return nil
+}
 
-		// Scripts
-		if app.cfg.Scripts != nil {
-			scripts := *app.cfg.Scripts
-			for _, s := range scripts {
-				g.AddScript(&s)
+// Check the warning policies of the project and its targets.
+func (app *Application) validateWarnings() error {
+	if app.cfg.Warnings != nil && !cmake.ValidWarningLevel(app.cfg.Warnings.Level) {
+		return configurationError(fmt.Errorf("invalid warning level (none, default, strict, or pedantic): %s",
+			app.cfg.Warnings.Level))
+	}
+
+	if app.cfg.Targets != nil {
+		for _, t := range *app.cfg.Targets {
+			if t.Warnings != nil && !cmake.ValidWarningLevel(t.Warnings.Level) {
+				return configurationError(fmt.Errorf("invalid warning level of %s (none, default, strict, or pedantic): %s",
+					t.Name, t.Warnings.Level))
 			}
 		}
+	}
+
+	return nil
+}
+
+// Returns an error if a test is registered twice or if its name cannot be told
+// apart from the names of the functions.
+func (app *Application) validateTests() error {
+	names := map[string]string{}
+
+	for _, t := range app.Targets() {
+		if _, found := discover.Frameworks[t.Discover]; len(t.Discover) > 0 && (!found || t.Type != "test") {
+			return configurationError(fmt.Errorf("invalid test discovery of %s (%s on test targets): %s",
+				t.Name, strings.Join(discover.Names(), ", "), t.Discover))
+		}
+
+		for _, test := range t.Tests {
+			if strings.Contains(test.Name, "/") {
+				return configurationError(fmt.Errorf("test name of %s cannot contain '/': %s", t.Name, test.Name))
+			}
 
-		g.Call("include", cmake.Quote("${SNAKE_DIR}/snake.4.cmake"))
+			for _, name := range test.Cases {
+				if other, found := names[name]; found {
+					return configurationError(fmt.Errorf("test %s is registered by %s and %s", name, other, t.Name))
+				}
 
-		if err = g.Save(cmakeListsTxt); err != nil {
-			return err
+				names[name] = t.Name
+			}
 		}
+	}
+
+	return nil
+}
 
+// Returns an error if a script forwards its arguments without a shell. The
+// CMake targets of scripts can only read the arguments from the environment.
+func (app *Application) validateScripts() error {
+	if app.cfg.Scripts == nil {
 		return nil
+	}
+
+	for _, s := range *app.cfg.Scripts {
+		options := []configuration.ScriptOptions{s.ScriptOptions}
+
+		for profile := range s.Profiles {
+			options = append(options, s.ForProfile(profile))
+		}
+
+		for _, o := range options {
+			if o.Shell == "sh" || o.Shell == "bash" {
+				continue
+			}
+
+			for _, command := range o.Commands {
+				if strings.Contains(command, "{{args}}") {
+					return configurationError(fmt.Errorf("script %s forwards its arguments ({{args}}) to its CMake target without shell: sh or bash",
+						s.Name))
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Re-generate the CMakeLists.txt",
+	RunE: func(c *cobra.Command, args []string) error {
+		return app.Generate()
 	},
 }
@@ -0,0 +1,81 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/sumartian-studios/snake/graph"
+)
+
+var graphFormatFlag string
+var graphReverseFlag bool
+var graphExternalFlag bool
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [target...]",
+	Short: "Export the target dependency graph (DOT, Mermaid, or JSON)",
+	RunE: func(c *cobra.Command, args []string) error {
+		if err := app.initSlow(); err != nil {
+			return err
+		}
+
+		g := graph.New(app.cfg)
+
+		for _, name := range args {
+			if g.Node(name) == nil {
+				return usageError(fmt.Errorf("unknown target or library: %s", name))
+			}
+		}
+
+		if len(args) > 0 {
+			g = g.Subgraph(g.Reachable(args, graphReverseFlag))
+		} else if graphReverseFlag {
+			return usageError(fmt.Errorf("--reverse requires a target"))
+		}
+
+		if !graphExternalFlag {
+			keep := map[string]bool{}
+
+			for _, n := range g.Nodes {
+				keep[n.Name] = n.Kind == graph.KindTarget
+			}
+
+			g = g.Subgraph(keep)
+		}
+
+		for _, cycle := range g.Cycles() {
+			fmt.Fprintln(app.ErrOrStderr(), "warning: dependency cycle:", strings.Join(cycle, " <-> "))
+		}
+
+		switch graphFormatFlag {
+		case "dot":
+			return g.WriteDOT(app.OutOrStdout())
+		case "mermaid":
+			return g.WriteMermaid(app.OutOrStdout())
+		case "json":
+			return g.WriteJSON(app.OutOrStdout())
+		}
+
+		return usageError(fmt.Errorf("unsupported graph format: %s", graphFormatFlag))
+	},
+}
+
+func init() {
+	graphCmd.Flags().StringVarP(&graphFormatFlag,
+		"output", "o", "dot",
+		"Output format (dot, mermaid, or json)")
+
+	graphCmd.Flags().BoolVarP(&graphReverseFlag,
+		"reverse", "r", false,
+		"Show the targets depending on the given targets instead")
+
+	graphCmd.Flags().BoolVar(&graphExternalFlag,
+		"external", true,
+		"Include external packages and libraries")
+}
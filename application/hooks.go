@@ -0,0 +1,94 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"fmt"
+
+	"github.com/sumartian-studios/snake/configuration"
+)
+
+// Returns the hooks registered for a lifecycle stage (ex. pre-build).
+func (app *Application) hooksFor(stage string) []configuration.Hook {
+	h := app.cfg.Hooks
+
+	if h == nil {
+		return nil
+	}
+
+	switch stage {
+	case "pre-configure":
+		return h.PreConfigure
+	case "post-configure":
+		return h.PostConfigure
+	case "pre-build":
+		return h.PreBuild
+	case "post-build":
+		return h.PostBuild
+	case "pre-test":
+		return h.PreTest
+	case "post-test":
+		return h.PostTest
+	case "pre-package":
+		return h.PrePackage
+	}
+
+	return nil
+}
+
+// Check the scripts and the failure policies of the hooks.
+func (app *Application) validateHooks() error {
+	for _, stage := range configuration.HookStages {
+		for _, hook := range app.hooksFor(stage) {
+			if !app.isScript(hook.Script) {
+				return configurationError(fmt.Errorf("%s hook: unknown script: %s", stage, hook.Script))
+			}
+
+			if hook.OnFailure != "" && hook.OnFailure != "abort" && hook.OnFailure != "warn" {
+				return configurationError(fmt.Errorf("%s hook: invalid on-failure value (abort or warn): %s",
+					stage, hook.OnFailure))
+			}
+		}
+	}
+
+	return nil
+}
+
+// Run the hooks of a lifecycle stage. A failing hook aborts the command unless
+// it is marked with "on-failure: warn".
+func (app *Application) runHooks(stage string) error {
+	if app.hooking {
+		return nil
+	}
+
+	app.hooking = true
+	defer func() { app.hooking = false }()
+
+	for _, hook := range app.hooksFor(stage) {
+		r := app.newScriptRunner()
+
+		r.Env = append(r.Env,
+			"SNAKE_HOOK="+stage,
+			"SNAKE_PROFILE="+r.Profile,
+			"SNAKE_BUILD_DIR="+app.db.ProfilePath,
+			"SNAKE_PROJECT_VERSION="+app.cfg.Version,
+		)
+
+		err := r.Run([]string{hook.Script})
+
+		if err == nil {
+			continue
+		}
+
+		if hook.OnFailure != "warn" {
+			return fmt.Errorf("%s hook failed: %w", stage, app.scriptError(err))
+		}
+
+		fmt.Fprintf(app.ErrOrStderr(), "warning: %s hook failed: %v\n", stage, err)
+	}
+
+	return nil
+}
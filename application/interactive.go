@@ -6,6 +6,9 @@
 package application
 
 import (
+	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
@@ -13,15 +16,25 @@ import (
 	"github.com/sumartian-studios/snake/repl"
 )
 
+// Reset the flags of every command (including nested commands) to their default
+// values between the commands of the REPL.
 func (app *Application) resetFlags() {
-	for _, c := range app.Commands() {
-		c.Flags().VisitAll(func(f *pflag.Flag) {
-			if f.Changed {
-				f.Value.Set(f.DefValue)
-				f.Changed = false
-			}
-		})
+	var reset func(commands []*cobra.Command)
+
+	reset = func(commands []*cobra.Command) {
+		for _, c := range commands {
+			c.Flags().VisitAll(func(f *pflag.Flag) {
+				if f.Changed {
+					f.Value.Set(f.DefValue)
+					f.Changed = false
+				}
+			})
+
+			reset(c.Commands())
+		}
 	}
+
+	reset(app.Commands())
 }
 
 func startInteractiveMode(cmd *cobra.Command, args []string) error {
@@ -35,11 +48,17 @@ func startInteractiveMode(cmd *cobra.Command, args []string) error {
 	r.History.Path = filepath.Join(app.snakeDir, "snake.history.txt")
 
 	r.Runner = func(args []string) error {
-		// Need to reset the flags after each call otherwise
-		// our flags will be stuck.
-		app.resetFlags()
-		app.SetArgs(args)
-		return app.Execute()
+		err := app.run(args...)
+
+		// Commands listen to interrupts while they run but the REPL must keep
+		// ignoring them.
+		signal.Ignore(os.Interrupt)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+
+		return err
 	}
 
 	for _, cmd := range app.Commands() {
@@ -91,6 +110,29 @@ func startInteractiveMode(cmd *cobra.Command, args []string) error {
 					suggestion.AddChild(script.Name)
 				}
 			}
+		case "graph":
+			if app.cfg.Targets != nil {
+				targets := *app.cfg.Targets
+				for _, target := range targets {
+					suggestion.AddChild(target.Name)
+				}
+			}
+		case "why":
+			if app.cfg.Dependencies != nil {
+				dependencies := *app.cfg.Dependencies
+				for _, d := range dependencies {
+					for _, i := range d.Imports {
+						suggestion.AddChild(i.Name)
+					}
+				}
+			}
+
+			if app.cfg.Targets != nil {
+				targets := *app.cfg.Targets
+				for _, target := range targets {
+					suggestion.AddChild(target.Name)
+				}
+			}
 		case "run":
 			if app.cfg.Targets != nil {
 				targets := *app.cfg.Targets
@@ -116,6 +158,10 @@ func startInteractiveMode(cmd *cobra.Command, args []string) error {
 
 									for _, test := range tests {
 										suggestion.AddChild(test.Name)
+
+										for _, f := range test.Functions {
+											suggestion.AddChild(test.CaseName(f))
+										}
 									}
 								}
 							}
@@ -123,6 +169,9 @@ func startInteractiveMode(cmd *cobra.Command, args []string) error {
 					}
 				}
 			}
+
+			// Discovered tests change after each build.
+			suggestion.Dynamic = app.discoveredTestNames
 		}
 	}
 
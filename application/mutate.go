@@ -70,11 +70,11 @@ var mutateCmd = &cobra.Command{
 	Short: "Transform or optimize resource files",
 	RunE: func(c *cobra.Command, args []string) error {
 		if len(mutatorFlag) == 0 {
-			return errors.New("you need to specify a mutator")
+			return usageError(errors.New("you need to specify a mutator"))
 		}
 
 		if len(args) < 2 {
-			return errors.New("you need to pass input path as 'arg1' and output path as 'arg2'")
+			return usageError(errors.New("you need to pass input path as 'arg1' and output path as 'arg2'"))
 		}
 
 		inputPath, outputPath := args[0], args[1]
@@ -101,7 +101,7 @@ var mutateCmd = &cobra.Command{
 				return err
 			}
 		default:
-			return fmt.Errorf("unsupported mutator: %s", mutatorFlag)
+			return usageError(fmt.Errorf("unsupported mutator: %s", mutatorFlag))
 		}
 
 		fmt.Println(mutatorFlag, inputPath)
@@ -7,40 +7,52 @@ package application
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 )
 
-func listOptions() error {
-	if app.cfg.Features == nil {
-		fmt.Println("No options available")
-		return nil
-	}
+// OptionInfo describes a global option for machine-readable output.
+type OptionInfo struct {
+	Key         string `json:"key" yaml:"key"`
+	Value       string `json:"value" yaml:"value"`
+	Description string `json:"description" yaml:"description"`
+	Condition   string `json:"condition" yaml:"condition"`
+}
 
-	feats := *app.cfg.Features
+var optionsOutputFlag string
 
-	for _, feat := range feats {
-		var key, description, value = "?", "?", "?"
+func (app *Application) listOptions(format string) error {
+	options := []OptionInfo{}
 
-		if feat.Key != nil {
-			key = *feat.Key
-			fmt.Println("Warning: key is empty")
-		}
+	if app.cfg.Features != nil {
+		for _, feat := range *app.cfg.Features {
+			// Features without a key only add definitions or scripts.
+			if feat.Key == nil {
+				continue
+			}
 
-		if feat.Description != nil {
-			description = *feat.Description
-			fmt.Println("Warning: description is empty")
+			options = append(options, OptionInfo{
+				Key:         *feat.Key,
+				Value:       stringOr(feat.Value, ""),
+				Description: stringOr(feat.Description, ""),
+				Condition:   stringOr(feat.Condition, ""),
+			})
 		}
+	}
 
-		if feat.Value != nil {
-			value = *feat.Value
-			fmt.Println("Warning: value is empty")
+	return app.printOutput(format, options, func(w io.Writer) error {
+		if len(options) == 0 {
+			fmt.Fprintln(w, "No options available")
+			return nil
 		}
 
-		fmt.Println("--", key+"="+value, fmt.Sprintf("\033[0;90m%s\033[0m", description))
-	}
+		for _, option := range options {
+			fmt.Fprintf(w, "-- %s=%s\t\033[0;90m%s\033[0m\n", option.Key, option.Value, option.Description)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 var listOptionsCmd = &cobra.Command{
@@ -51,6 +63,19 @@ var listOptionsCmd = &cobra.Command{
 			return err
 		}
 
-		return listOptions()
+		return app.listOptions(optionsOutputFlag)
 	},
 }
+
+func init() {
+	addOutputFlag(listOptionsCmd, &optionsOutputFlag)
+}
+
+// Returns the string or the default value if it is nil.
+func stringOr(s *string, defaultValue string) string {
+	if s != nil {
+		return *s
+	}
+
+	return defaultValue
+}
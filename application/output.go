@@ -0,0 +1,52 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Register the --output flag on a listing command. Each command has its own
+// variable so nested commands do not share their format.
+func addOutputFlag(c *cobra.Command, format *string) {
+	c.Flags().StringVarP(format, "output", "o", "table", "Output format (json, yaml, or table)")
+}
+
+// Print the value in the format selected by --output. The table callback is
+// used for human readable output and receives a tab-separated writer.
+func (app *Application) printOutput(format string, v interface{}, table func(w io.Writer) error) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(app.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case "yaml":
+		encoder := yaml.NewEncoder(app.OutOrStdout())
+		encoder.SetIndent(2)
+
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+
+		return encoder.Close()
+	case "table", "":
+		writer := tabwriter.NewWriter(app.OutOrStdout(), 0, 8, 2, ' ', 0)
+
+		if err := table(writer); err != nil {
+			return err
+		}
+
+		return writer.Flush()
+	}
+
+	return usageError(fmt.Errorf("unsupported output format: %s", format))
+}
@@ -21,6 +21,10 @@ var packageCmd = &cobra.Command{
 			return err
 		}
 
+		if err := app.runHooks("pre-package"); err != nil {
+			return err
+		}
+
 		if err := app.launch("cmake", "--build", app.db.ProfilePath, "--", "package"); err != nil {
 			return err
 		}
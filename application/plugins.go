@@ -0,0 +1,265 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Executables named "snake-<command>" extend Snake with new commands.
+const pluginPrefix = "snake-"
+
+// Context passed to plugins through the SNAKE_CONTEXT environment variable.
+type PluginContext struct {
+	RootDir           string `json:"root-dir"`
+	SnakeDir          string `json:"snake-dir"`
+	Profile           string `json:"profile"`
+	BuildPath         string `json:"build-path"`
+	ConfigurationPath string `json:"configuration-path"`
+	Version           string `json:"version"`
+}
+
+// Returns the root directory passed on the command-line. Plugins are discovered
+// before the flags are parsed.
+func rootDirArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		} else if strings.HasPrefix(arg, "--root-dir=") {
+			return strings.TrimPrefix(arg, "--root-dir=")
+		} else if arg == "--root-dir" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+
+	return "."
+}
+
+// Returns the plugins found in the project ".snake/bin" directory and on the PATH
+// by command name. Project plugins take precedence.
+func findPlugins(rootDir string) map[string]string {
+	plugins := map[string]string{}
+
+	dirs := append([]string{filepath.Join(rootDir, ".snake", "bin")},
+		filepath.SplitList(os.Getenv("PATH"))...)
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			name := e.Name()
+
+			if !strings.HasPrefix(name, pluginPrefix) || e.IsDir() {
+				continue
+			}
+
+			if runtime.GOOS == "windows" {
+				if !strings.EqualFold(filepath.Ext(name), ".exe") {
+					continue
+				}
+
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			} else if info, err := e.Info(); err != nil || info.Mode()&0111 == 0 {
+				continue
+			}
+
+			command := strings.TrimPrefix(name, pluginPrefix)
+
+			if _, ok := plugins[command]; !ok && len(command) > 0 {
+				plugins[command] = filepath.Join(dir, e.Name())
+			}
+		}
+	}
+
+	return plugins
+}
+
+// Register the discovered plugins as commands so they show up in the help and
+// in the interactive mode. Built-in commands cannot be overridden. Only called
+// when the command line needs the plugins (see needsPlugins).
+func (app *Application) addPlugins(args []string) {
+	plugins := findPlugins(rootDirArg(args))
+	names := []string{}
+
+	for name := range plugins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if c, _, err := app.Find([]string{name}); err == nil && c != &app.Command {
+			continue
+		}
+
+		path := plugins[name]
+		app.plugins[name] = path
+
+		app.AddCommand(&cobra.Command{
+			Use:                name,
+			Short:              "External command (" + path + ")",
+			DisableFlagParsing: true,
+			RunE: func(c *cobra.Command, args []string) error {
+				return app.runPlugin(path, args)
+			},
+		})
+	}
+}
+
+// Returns the index of the command name in the arguments (the length of the
+// arguments if there is none) and true if the help or the version is requested
+// before it.
+func commandIndex(args []string) (int, bool) {
+	i, info := 0, false
+
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		switch args[i] {
+		case "--root-dir", "--snake-dir":
+			i += 2
+		case "-h", "--help", "-v", "--version":
+			info = true
+			i++
+		default:
+			i++
+		}
+	}
+
+	return i, info
+}
+
+// Returns true if the plugins must be found to run the command line: the command
+// is not built-in or the commands are listed by the help, the shell completion,
+// or the interactive mode. Searching the PATH is otherwise skipped.
+func (app *Application) needsPlugins(args []string) bool {
+	i, info := commandIndex(args)
+
+	// Without a command, Snake prints the help or the version or starts the
+	// interactive mode.
+	if i >= len(args) {
+		for _, arg := range args {
+			if arg == "-h" || arg == "--help" {
+				return true
+			}
+		}
+
+		return !info
+	}
+
+	switch args[i] {
+	case "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+
+	c, _, err := app.Find(args[i : i+1])
+
+	return err != nil || c == &app.Command
+}
+
+// Run a plugin if it is the requested command. Snake flags placed before the
+// command name are parsed and everything after it is forwarded untouched.
+func (app *Application) dispatchPlugin(args []string) (bool, error) {
+	i, info := commandIndex(args)
+
+	if info || i >= len(args) {
+		return false, nil
+	}
+
+	path, ok := app.plugins[args[i]]
+
+	if !ok {
+		return false, nil
+	}
+
+	if err := app.PersistentFlags().Parse(args[:i]); err != nil {
+		return true, usageError(err)
+	}
+
+	return true, app.runPlugin(path, args[i+1:])
+}
+
+// Write the configuration to a JSON file using the same keys as the YAML file.
+func (app *Application) writeConfigurationJSON() (string, error) {
+	data, err := yaml.Marshal(app.cfg)
+
+	if err != nil {
+		return "", err
+	}
+
+	var v interface{}
+
+	if err = yaml.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+
+	if data, err = json.MarshalIndent(v, "", "  "); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(app.snakeDir, "snake.configuration.json")
+
+	return path, os.WriteFile(path, data, 0666)
+}
+
+// Run a plugin with the project context. Plugins also work outside of Snake projects
+// in which case only the directories are provided.
+func (app *Application) runPlugin(path string, args []string) error {
+	if err := app.init(); err != nil {
+		return err
+	}
+
+	ctx := PluginContext{
+		RootDir:  app.rootDir,
+		SnakeDir: app.snakeDir,
+		Version:  VersionStr,
+	}
+
+	if _, err := os.Stat(app.configPath); err == nil {
+		if err := app.initSlow(); err != nil {
+			return err
+		}
+
+		if ctx.ConfigurationPath, err = app.writeConfigurationJSON(); err != nil {
+			return err
+		}
+
+		if exists, profile := app.getCurrentProfile(); exists {
+			ctx.Profile = profile.Name
+			ctx.BuildPath = app.db.ProfilePath
+		}
+	}
+
+	data, err := json.Marshal(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Env = append(os.Environ(),
+		"SNAKE_CONTEXT="+string(data),
+		"SNAKE_ROOT_DIR="+ctx.RootDir,
+		"SNAKE_DIR="+ctx.SnakeDir,
+		"SNAKE_PROFILE="+ctx.Profile,
+		"SNAKE_BUILD_DIR="+ctx.BuildPath,
+		"SNAKE_CONFIGURATION="+ctx.ConfigurationPath,
+		"SNAKE_VERSION="+ctx.Version,
+	)
+
+	return app.launchInteractive(cmd)
+}
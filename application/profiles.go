@@ -7,31 +7,87 @@ package application
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/sumartian-studios/snake/configuration"
 )
 
-func listProfiles() error {
-	var s string
+// ProfileInfo describes a profile for machine-readable output.
+type ProfileInfo struct {
+	Name         string            `json:"name" yaml:"name"`
+	Description  string            `json:"description" yaml:"description"`
+	Type         string            `json:"type" yaml:"type"`
+	System       string            `json:"system" yaml:"system"`
+	Arch         string            `json:"arch" yaml:"arch"`
+	Compiler     string            `json:"compiler" yaml:"compiler"`
+	Options      map[string]string `json:"options" yaml:"options"`
+	LinkFlags    []string          `json:"link-flags" yaml:"link-flags"`
+	CompileFlags []string          `json:"compile-flags" yaml:"compile-flags"`
+	Sanitizers   []string          `json:"sanitizers" yaml:"sanitizers"`
+	Coverage     bool              `json:"coverage" yaml:"coverage"`
+	Current      bool              `json:"current" yaml:"current"`
+}
 
-	if len(app.cfg.Profiles) < 1 {
-		fmt.Println("No profiles available")
-		return nil
+// Merge the option maps of a profile. Later maps override earlier ones.
+func resolveProfileOptions(p *configuration.Profile) map[string]string {
+	options := map[string]string{}
+
+	for _, mapping := range p.Variables {
+		for k, v := range mapping {
+			options[k] = v
+		}
 	}
 
+	return options
+}
+
+// Returns the profiles of the loaded configuration.
+func (app *Application) Profiles() []ProfileInfo {
+	profiles := []ProfileInfo{}
+
 	for i, profile := range app.cfg.Profiles {
-		if i == app.db.ProfileIndex {
-			s = "-- [x] " + profile.Name + " (current)"
-		} else {
-			s = "-- [ ] " + profile.Name
+		profiles = append(profiles, ProfileInfo{
+			Name:         profile.Name,
+			Description:  profile.Description,
+			Type:         profile.Type,
+			System:       profile.System,
+			Arch:         profile.Arch,
+			Compiler:     profile.Compiler,
+			Options:      resolveProfileOptions(&app.cfg.Profiles[i]),
+			LinkFlags:    append([]string{}, profile.LinkFlags...),
+			CompileFlags: append([]string{}, profile.CompileFlags...),
+			Sanitizers:   append([]string{}, profile.Sanitizers...),
+			Coverage:     profile.Coverage,
+			Current:      i == app.db.ProfileIndex && len(app.db.ProfilePath) > 0,
+		})
+	}
+
+	return profiles
+}
+
+var profilesOutputFlag string
+
+func (app *Application) listProfiles(format string) error {
+	profiles := app.Profiles()
+
+	return app.printOutput(format, profiles, func(w io.Writer) error {
+		if len(profiles) < 1 {
+			fmt.Fprintln(w, "No profiles available")
+			return nil
 		}
 
-		fmt.Println(s)
-	}
+		for _, profile := range profiles {
+			if profile.Current {
+				fmt.Fprintln(w, "-- [x] "+profile.Name+" (current)\t"+profile.Description)
+			} else {
+				fmt.Fprintln(w, "-- [ ] "+profile.Name+"\t"+profile.Description)
+			}
+		}
 
-	return nil
+		return nil
+	})
 }
 
 var listProfilesCmd = &cobra.Command{
@@ -42,10 +98,14 @@ var listProfilesCmd = &cobra.Command{
 			return err
 		}
 
-		return listProfiles()
+		return app.listProfiles(profilesOutputFlag)
 	},
 }
 
+func init() {
+	addOutputFlag(listProfilesCmd, &profilesOutputFlag)
+}
+
 // Returns the current profile if it exists. If it does not exist it returns false and nil.
 func (app *Application) getCurrentProfile() (bool, *configuration.Profile) {
 	if len(app.db.ProfilePath) > 0 && len(app.cfg.Profiles) > app.db.ProfileIndex {
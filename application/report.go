@@ -0,0 +1,322 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sumartian-studios/snake/junit"
+)
+
+// Test report formats supported by 'snake test --report'.
+var testReportFormats = []string{"junit", "json"}
+
+// A test report requested with --report format=path.
+type TestReport struct {
+	// The report format (junit or json).
+	Format string
+
+	// The path of the report.
+	Path string
+}
+
+// Parse the value of --report (ex. junit=results.xml).
+func parseTestReport(value string) (TestReport, error) {
+	format, path, found := strings.Cut(value, "=")
+
+	if !found || len(path) == 0 {
+		return TestReport{}, fmt.Errorf("expected format=path: %s", value)
+	}
+
+	for _, f := range testReportFormats {
+		if f == format {
+			return TestReport{Format: format, Path: path}, nil
+		}
+	}
+
+	return TestReport{}, fmt.Errorf("unsupported format (%s): %s", strings.Join(testReportFormats, ", "), format)
+}
+
+// TestCaseResult is the result of a test enriched with the Snake metadata.
+type TestCaseResult struct {
+	// The CTest name of the test.
+	Name string `json:"name"`
+
+	// The target running the test.
+	Target string `json:"target"`
+
+	// The test functions run by the test.
+	Functions []string `json:"functions"`
+
+	// One of "passed", "failed", or "skipped".
+	Status string `json:"status"`
+
+	// Duration of the last attempt (in seconds).
+	Duration float64 `json:"duration"`
+
+	// Number of times the test was run (more than 1 if it was retried).
+	Attempts int `json:"attempts"`
+
+	// Failures of the test do not fail the run.
+	Quarantined bool `json:"quarantined,omitempty"`
+
+	// The failure or skip reason.
+	Message string `json:"message,omitempty"`
+
+	// The output of the test.
+	Output string `json:"output,omitempty"`
+}
+
+// TestRunResult is the result of 'snake test'.
+type TestRunResult struct {
+	Project   string           `json:"project"`
+	Profile   string           `json:"profile"`
+	Timestamp string           `json:"timestamp"`
+	Duration  float64          `json:"duration"`
+	Passed    int              `json:"passed"`
+	Retried   int              `json:"retried"`
+	Failed    int              `json:"failed"`
+	Skipped   int              `json:"skipped"`
+	Tests     []TestCaseResult `json:"tests"`
+}
+
+// Count the tests by status. Tests passing on retry are also counted as passed.
+func (result *TestRunResult) count() {
+	result.Passed, result.Retried, result.Failed, result.Skipped = 0, 0, 0, 0
+
+	for _, t := range result.Tests {
+		switch t.Status {
+		case "passed":
+			result.Passed++
+
+			if t.Attempts > 1 {
+				result.Retried++
+			}
+		case "failed":
+			result.Failed++
+		case "skipped":
+			result.Skipped++
+		}
+	}
+}
+
+// Returns the names of the failed tests.
+func (result *TestRunResult) failed() []string {
+	names := []string{}
+
+	for _, t := range result.Tests {
+		if t.Status == "failed" {
+			names = append(names, t.Name)
+		}
+	}
+
+	return names
+}
+
+// Replace the results of the retried tests.
+func (result *TestRunResult) merge(retry *TestRunResult) {
+	for _, r := range retry.Tests {
+		for i, t := range result.Tests {
+			if t.Name == r.Name {
+				r.Attempts = t.Attempts + 1
+				result.Tests[i] = r
+			}
+		}
+	}
+
+	result.Duration += retry.Duration
+	result.count()
+}
+
+// The target and the functions of a CTest test.
+type testMetadata struct {
+	target    string
+	functions []string
+}
+
+// Returns the metadata of the tests by CTest name.
+func (app *Application) testMetadata() map[string]testMetadata {
+	tests := map[string]testMetadata{}
+
+	for _, t := range app.Targets() {
+		for _, test := range t.Tests {
+			if len(test.Functions) == 0 {
+				tests[test.Name] = testMetadata{target: t.Name, functions: []string{}}
+			}
+
+			for i, f := range test.Functions {
+				tests[test.Cases[i]] = testMetadata{target: t.Name, functions: []string{f}}
+			}
+		}
+	}
+
+	if exists, profile := app.getCurrentProfile(); exists {
+		for target, discovered := range app.db.Discovered[profile.Name] {
+			for _, c := range discovered.Cases {
+				tests[discoveredTestName(target, c)] = testMetadata{target: target, functions: []string{c}}
+			}
+		}
+	}
+
+	return tests
+}
+
+// Path of the JUnit results written by CTest.
+func (app *Application) ctestResultsPath() string {
+	return filepath.Join(app.db.ProfilePath, "snake.ctest.xml")
+}
+
+// Read the results written by CTest and add the Snake metadata.
+func (app *Application) readTestResults(profile string, start time.Time, duration time.Duration) (*TestRunResult, error) {
+	file, err := os.Open(app.ctestResultsPath())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	suite, err := junit.ReadSuite(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the CTest results: %w", err)
+	}
+
+	result := &TestRunResult{
+		Project:   app.cfg.Project,
+		Profile:   profile,
+		Timestamp: start.UTC().Format(time.RFC3339),
+		Duration:  duration.Seconds(),
+		Tests:     []TestCaseResult{},
+	}
+
+	metadata := app.testMetadata()
+
+	for _, c := range suite.TestCases {
+		test := TestCaseResult{
+			Name:        c.Name,
+			Target:      metadata[c.Name].target,
+			Functions:   append([]string{}, metadata[c.Name].functions...),
+			Status:      "passed",
+			Duration:    c.Time,
+			Attempts:    1,
+			Quarantined: app.quarantined(c.Name),
+			Output:      c.SystemOut,
+		}
+
+		if c.Failure != nil {
+			test.Status, test.Message = "failed", c.Failure.Message
+		} else if c.Skipped != nil {
+			test.Status, test.Message = "skipped", c.Skipped.Message
+		}
+
+		result.Tests = append(result.Tests, test)
+	}
+
+	result.count()
+
+	return result, nil
+}
+
+// Convert the results to a JUnit report with a single suite named after the
+// profile. The class name of each test case is its target.
+func (result *TestRunResult) junit() *junit.TestSuites {
+	suite := junit.TestSuite{
+		Name:      result.Profile,
+		Tests:     len(result.Tests),
+		Failures:  result.Failed,
+		Skipped:   result.Skipped,
+		Time:      result.Duration,
+		Timestamp: result.Timestamp,
+		Properties: []junit.Property{
+			{Name: "project", Value: result.Project},
+			{Name: "profile", Value: result.Profile},
+		},
+	}
+
+	for _, t := range result.Tests {
+		c := junit.TestCase{
+			Name:       t.Name,
+			ClassName:  t.Target,
+			Time:       t.Duration,
+			Properties: []junit.Property{{Name: "profile", Value: result.Profile}},
+			SystemOut:  t.Output,
+		}
+
+		// Tests added outside of the configuration have no metadata.
+		if len(t.Target) > 0 {
+			c.Properties = append(c.Properties,
+				junit.Property{Name: "target", Value: t.Target},
+				junit.Property{Name: "functions", Value: strings.Join(t.Functions, " ")})
+		} else {
+			c.ClassName = t.Name
+		}
+
+		if t.Attempts > 1 {
+			c.Properties = append(c.Properties, junit.Property{Name: "attempts", Value: fmt.Sprint(t.Attempts)})
+		}
+
+		if t.Quarantined {
+			c.Properties = append(c.Properties, junit.Property{Name: "quarantined", Value: "true"})
+		}
+
+		switch t.Status {
+		case "failed":
+			c.Failure = &junit.Message{Message: t.Message}
+		case "skipped":
+			c.Skipped = &junit.Message{Message: t.Message}
+		}
+
+		suite.TestCases = append(suite.TestCases, c)
+	}
+
+	return &junit.TestSuites{
+		Name:       result.Project,
+		Tests:      suite.Tests,
+		Failures:   suite.Failures,
+		Skipped:    suite.Skipped,
+		Time:       suite.Time,
+		TestSuites: []junit.TestSuite{suite},
+	}
+}
+
+// Write the requested test reports.
+func (app *Application) writeTestReports(result *TestRunResult, reports []TestReport) error {
+	for _, r := range reports {
+		file, err := os.Create(r.Path)
+
+		if err != nil {
+			return err
+		}
+
+		switch r.Format {
+		case "junit":
+			err = junit.Write(file, result.junit())
+		case "json":
+			encoder := json.NewEncoder(file)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(result)
+		}
+
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		if err = file.Close(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(app.OutOrStdout(), "Test report (%s) written to %s\n", r.Format, r.Path)
+	}
+
+	return nil
+}
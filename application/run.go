@@ -7,31 +7,109 @@ package application
 
 import (
 	"errors"
-	"fmt"
+	"os/exec"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/sumartian-studios/snake/configuration"
+	"github.com/sumartian-studios/snake/runner"
 )
 
+// Returns true if the name refers to a script.
+func (app *Application) isScript(name string) bool {
+	if app.cfg.Scripts != nil {
+		for _, s := range *app.cfg.Scripts {
+			if s.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Create a native runner for the project scripts.
+func (app *Application) newScriptRunner() *runner.Runner {
+	scripts := []configuration.Script{}
+
+	if app.cfg.Scripts != nil {
+		scripts = *app.cfg.Scripts
+	}
+
+	r := runner.New(scripts, app.rootDir)
+
+	// Scripts are written for add_custom_target so we provide the common variables.
+	r.Variables["CMAKE_SOURCE_DIR"] = app.rootDir
+	r.Variables["CMAKE_BINARY_DIR"] = app.db.ProfilePath
+	r.Variables["CMAKE_COMMAND"] = "cmake"
+	r.Variables["PROJECT_NAME"] = app.cfg.Project
+	r.Variables["PROJECT_VERSION"] = app.cfg.Version
+	r.Variables["SNAKE_DIR"] = app.snakeDir
+
+	if exists, profile := app.getCurrentProfile(); exists {
+		r.Profile = profile.Name
+	}
+
+	if app.cfg.Targets != nil {
+		for _, t := range *app.cfg.Targets {
+			r.Targets[t.Name] = true
+		}
+	}
+
+	r.Context = app.context()
+	r.Stdout = app.OutOrStdout()
+	r.Stderr = app.ErrOrStderr()
+
+	r.Build = func(targets []string) error {
+		return app.Build(BuildOptions{Targets: targets})
+	}
+
+	return r
+}
+
+// Run scripts natively and forward the exit code of a failed command.
+func (app *Application) runScripts(names []string, args []string) error {
+	r := app.newScriptRunner()
+	r.Args = args
+
+	return app.scriptError(r.Run(names))
+}
+
+// Forward the exit code of a failed script command.
+func (app *Application) scriptError(err error) error {
+	var scriptErr *runner.ScriptError
+	var exitErr *ExitError
+
+	if errors.As(err, &scriptErr) {
+		return subprocessError(scriptErr.Script, scriptErr.Err)
+	} else if errors.As(err, &exitErr) {
+		return err
+	} else if err != nil {
+		return configurationError(err)
+	}
+
+	return nil
+}
+
 var runCmd = &cobra.Command{
 	Use:                "run",
 	Short:              "Run scripts or executable targets",
 	DisableFlagParsing: true,
 	RunE: func(c *cobra.Command, args []string) error {
-		err := app.initFast()
+		err := app.initSlow()
 
 		if err != nil {
 			return err
 		}
 
 		if len(args) < 1 {
-			return errors.New("you must specify a target to run")
+			return usageError(errors.New("you must specify a target or script to run"))
 		}
 
-		if err = app.launch(filepath.Join(app.db.ProfilePath, "bin", args[0]), args[1:]...); err != nil {
-			fmt.Println(err)
+		if app.isScript(args[0]) {
+			return app.runScripts(args[:1], args[1:])
 		}
 
-		return nil
+		return app.launchInteractive(exec.Command(filepath.Join(app.db.ProfilePath, "bin", args[0]), args[1:]...))
 	},
 }
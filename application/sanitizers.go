@@ -0,0 +1,64 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sanitizers supported by profiles.
+var supportedSanitizers = map[string]bool{
+	"address":   true,
+	"undefined": true,
+	"thread":    true,
+	"memory":    true,
+	"leak":      true,
+}
+
+// Pairs of sanitizers that cannot be used together.
+var incompatibleSanitizers = [][2]string{
+	{"address", "thread"},
+	{"address", "memory"},
+	{"thread", "memory"},
+	{"thread", "leak"},
+	{"memory", "leak"},
+}
+
+// Check the sanitizers of a profile.
+func validateSanitizers(profile string, sanitizers []string) error {
+	enabled := map[string]bool{}
+
+	for _, s := range sanitizers {
+		if !supportedSanitizers[s] {
+			return configurationError(fmt.Errorf("unsupported sanitizer in profile %s (address, undefined, thread, memory, or leak): %s",
+				profile, s))
+		}
+
+		enabled[s] = true
+	}
+
+	for _, pair := range incompatibleSanitizers {
+		if enabled[pair[0]] && enabled[pair[1]] {
+			return configurationError(fmt.Errorf("the %s and %s sanitizers cannot be used together (profile %s)",
+				pair[0], pair[1], profile))
+		}
+	}
+
+	return nil
+}
+
+// Returns the CMake options enabling the sanitizers and the coverage of a profile.
+func instrumentationOptions(sanitizers []string, coverage bool) []string {
+	// Both are cached so they are always passed to turn them off again.
+	options := []string{"-DSNAKE_SANITIZERS=" + strings.Join(sanitizers, ";"), "-DSNAKE_COVERAGE=off"}
+
+	if coverage {
+		options[1] = "-DSNAKE_COVERAGE=on"
+	}
+
+	return options
+}
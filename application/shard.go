@@ -0,0 +1,162 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A subset of the tests selected with --shard index/count.
+type TestShard struct {
+	// Index of the shard (starting at 1).
+	Index int
+
+	// Number of shards.
+	Count int
+}
+
+// Parse the value of --shard (ex. 2/4).
+func parseTestShard(value string) (TestShard, error) {
+	index, count, found := strings.Cut(value, "/")
+	i, err1 := strconv.Atoi(index)
+	n, err2 := strconv.Atoi(count)
+
+	if !found || err1 != nil || err2 != nil || n < 1 || i < 1 || i > n {
+		return TestShard{}, fmt.Errorf("expected index/count with 1 <= index <= count: %s", value)
+	}
+
+	return TestShard{Index: i, Count: n}, nil
+}
+
+// Returns the names of the tests selected by CTest arguments without running them.
+func (app *Application) listTests(args []string) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(app.context(), "ctest",
+		append([]string{"--test-dir", app.db.ProfilePath, "--show-only=json-v1"}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ctest --show-only: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var list struct {
+		Tests []struct {
+			Name string `json:"name"`
+		} `json:"tests"`
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &list); err != nil {
+		return nil, fmt.Errorf("unable to read the tests listed by CTest: %w", err)
+	}
+
+	names := []string{}
+
+	for _, t := range list.Tests {
+		names = append(names, t.Name)
+	}
+
+	return names, nil
+}
+
+// Returns the duration of each test (in seconds) from a JSON report of a previous
+// run (snake test --report json=path).
+func readTestDurations(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result TestRunResult
+
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unable to read the test durations of %s: %w", path, err)
+	}
+
+	durations := map[string]float64{}
+
+	for _, t := range result.Tests {
+		if t.Status != "skipped" {
+			durations[t.Name] = t.Duration
+		}
+	}
+
+	return durations, nil
+}
+
+// Returns the tests of a shard. The longest tests are assigned first to the shard
+// with the least work; tests without a duration take the average duration and
+// all the tests take the same time without durations. The split only depends on
+// the names and the durations so runners must use the same durations (ex. a
+// shared report) to compute complementary shards.
+func shardTests(names []string, durations map[string]float64, shard TestShard) []string {
+	average, known := 0.0, 0
+
+	for _, name := range names {
+		if d, ok := durations[name]; ok {
+			average += d
+			known++
+		}
+	}
+
+	if known > 0 {
+		average /= float64(known)
+	} else {
+		average = 1
+	}
+
+	duration := func(name string) float64 {
+		if d, ok := durations[name]; ok {
+			return d
+		}
+
+		return average
+	}
+
+	sorted := append([]string{}, names...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := duration(sorted[i]), duration(sorted[j])
+
+		if a != b {
+			return a > b
+		}
+
+		return sorted[i] < sorted[j]
+	})
+
+	loads := make([]float64, shard.Count)
+	selected := []string{}
+
+	for _, name := range sorted {
+		lightest := 0
+
+		for i := range loads {
+			if loads[i] < loads[lightest] {
+				lightest = i
+			}
+		}
+
+		loads[lightest] += duration(name)
+
+		if lightest == shard.Index-1 {
+			selected = append(selected, name)
+		}
+	}
+
+	sort.Strings(selected)
+
+	return selected
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShardTests(t *testing.T) {
+	tests := []struct {
+		name      string
+		names     []string
+		durations map[string]float64
+		count     int
+		want      [][]string
+	}{
+		{
+			name:      "longest tests first",
+			names:     []string{"a", "b", "c", "d", "e"},
+			durations: map[string]float64{"a": 8, "b": 5, "c": 4, "d": 3, "e": 1},
+			count:     2,
+			want:      [][]string{{"a", "d"}, {"b", "c", "e"}},
+		},
+		{
+			name:      "ties are broken by name",
+			names:     []string{"d", "c", "b", "a"},
+			durations: map[string]float64{},
+			count:     2,
+			want:      [][]string{{"a", "c"}, {"b", "d"}},
+		},
+		{
+			name:      "unknown durations take the average",
+			names:     []string{"a", "b", "c", "new"},
+			durations: map[string]float64{"a": 6, "b": 2, "c": 1},
+			count:     2,
+			want:      [][]string{{"a"}, {"b", "c", "new"}},
+		},
+		{
+			name:      "more shards than tests",
+			names:     []string{"a", "b"},
+			durations: map[string]float64{"a": 1, "b": 2},
+			count:     3,
+			want:      [][]string{{"b"}, {"a"}, {}},
+		},
+		{
+			name:      "without durations",
+			names:     []string{"e", "d", "c", "b", "a"},
+			durations: map[string]float64{},
+			count:     3,
+			want:      [][]string{{"a", "d"}, {"b", "e"}, {"c"}},
+		},
+		{
+			name:      "single shard",
+			names:     []string{"b", "a"},
+			durations: map[string]float64{"a": 1},
+			count:     1,
+			want:      [][]string{{"a", "b"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, want := range test.want {
+				got := shardTests(test.names, test.durations, TestShard{Index: i + 1, Count: test.count})
+
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("shard %d/%d: got %q, want %q", i+1, test.count, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sumartian-studios/snake/configuration"
+	"github.com/sumartian-studios/snake/distribution"
+)
+
+// Environment variables read by CMake when looking for the toolchain.
+var toolchainEnv = []string{"CC", "CXX", "CFLAGS", "CXXFLAGS", "LDFLAGS"}
+
+// The inputs of the last successful configuration of a profile. CMake is not
+// invoked again as long as they do not change.
+type configureStamp struct {
+	Args   []string          `json:"args"`
+	Inputs map[string]string `json:"inputs"`
+}
+
+// Path to the stamp of the current profile.
+func (app *Application) stampPath() string {
+	return filepath.Join(app.db.ProfilePath, "snake.configure.json")
+}
+
+// Returns the SHA-256 of some data.
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Returns the SHA-256 of a file or an empty string if it cannot be read.
+func hashFile(path string) string {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return ""
+	}
+
+	return hashBytes(data)
+}
+
+// Identify an executable by its path, size, and modification time.
+func executableStamp(name string) string {
+	path, err := exec.LookPath(name)
+
+	if err != nil {
+		return ""
+	}
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return path
+	}
+
+	return fmt.Sprintf("%s %d %d", path, info.Size(), info.ModTime().UnixNano())
+}
+
+// Collect the inputs affecting the configuration of a profile.
+func (app *Application) configureInputs(profile *configuration.Profile) map[string]string {
+	inputs := map[string]string{
+		"CMakeLists.txt": hashFile(filepath.Join(app.rootDir, "CMakeLists.txt")),
+		".snake.yml":     hashFile(app.configPath),
+		"cmake":          executableStamp("cmake"),
+	}
+
+	if data, err := distribution.Archives.ReadFile(VersionStr + ".zip"); err == nil {
+		inputs["snake"] = VersionStr + " " + hashBytes(data)
+	}
+
+	compiler := profile.Compiler
+
+	if len(compiler) == 0 {
+		compiler = os.Getenv("CXX")
+	}
+
+	if len(compiler) > 0 {
+		inputs["compiler"] = executableStamp(compiler)
+	}
+
+	for _, name := range toolchainEnv {
+		if v, ok := os.LookupEnv(name); ok {
+			inputs["env:"+name] = v
+		}
+	}
+
+	// Sources are globbed during the configuration so adding or removing one
+	// requires running CMake again.
+	files := []string{}
+
+	for file := range listFiles(app.watchedPaths(), app.unwatchedPaths()) {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+
+	inputs["sources"] = hashBytes([]byte(strings.Join(files, "\n")))
+
+	return inputs
+}
+
+// Returns true if the stamp matches the last successful configuration and the
+// build directory still exists.
+func (app *Application) configurationUpToDate(stamp *configureStamp) bool {
+	if _, err := os.Stat(filepath.Join(app.db.ProfilePath, "CMakeCache.txt")); err != nil {
+		return false
+	}
+
+	data, err := os.ReadFile(app.stampPath())
+
+	if err != nil {
+		return false
+	}
+
+	var previous configureStamp
+
+	if err = json.Unmarshal(data, &previous); err != nil {
+		return false
+	}
+
+	if strings.Join(previous.Args, "\x00") != strings.Join(stamp.Args, "\x00") ||
+		len(previous.Inputs) != len(stamp.Inputs) {
+		return false
+	}
+
+	for k, v := range stamp.Inputs {
+		if previous.Inputs[k] != v {
+			if app.verbose {
+				fmt.Fprintln(app.OutOrStdout(), "Changed:", k)
+			}
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// Write the stamp after a successful configuration.
+func (app *Application) writeStamp(stamp *configureStamp) error {
+	data, err := json.MarshalIndent(stamp, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(app.stampPath(), data, 0666)
+}
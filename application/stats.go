@@ -0,0 +1,549 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sumartian-studios/snake/ninja"
+)
+
+var statsTopFlag int
+var statsTraceOutFlag string
+var statsOutputFlag string
+var statsBuildOutputFlag string
+
+// Maximum number of builds remembered per profile.
+const buildHistorySize = 50
+
+// A target is reported as a regression when it gets slower by this ratio and by
+// at least regressionMinimum milliseconds.
+const regressionRatio = 0.1
+const regressionMinimum = 100
+
+// Number of intervals of the parallelism report.
+const parallelismIntervals = 10
+
+// BuildRecord summarizes a build recorded in the Ninja log.
+type BuildRecord struct {
+	// Time at which the build was recorded (Unix time).
+	Time int64 `json:"Time"`
+
+	// Wall time of the build (in milliseconds).
+	Duration int `json:"Duration"`
+
+	// Sum of the step durations (in milliseconds).
+	Total int `json:"Total"`
+
+	// Number of steps.
+	Steps int `json:"Steps"`
+
+	// Sum of the step durations per target (in milliseconds).
+	Targets map[string]int `json:"Targets"`
+
+	// Identifies the build in the Ninja log so it is only recorded once.
+	Signature string `json:"Signature"`
+}
+
+// Matches the object directory of a CMake target.
+var objectDirRegex = regexp.MustCompile(`(?:^|/)CMakeFiles/([^/]+)\.dir/`)
+
+// Returns the target that produced an output of the build system or an empty string.
+func (app *Application) outputTarget(output string) string {
+	output = filepath.ToSlash(output)
+
+	if m := objectDirRegex.FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+
+	for _, component := range strings.Split(output, "/") {
+		if strings.HasSuffix(component, "_autogen") {
+			return strings.TrimSuffix(component, "_autogen")
+		}
+	}
+
+	if app.cfg.Targets == nil {
+		return ""
+	}
+
+	name := path.Base(output)
+
+	// Shared libraries may be versioned (ex. libfoo.so.1.2).
+	if i := strings.Index(name, ".so"); i != -1 {
+		name = name[:i]
+	}
+
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	for _, t := range *app.cfg.Targets {
+		if name == t.Name || name == "lib"+t.Name {
+			return t.Name
+		}
+	}
+
+	return ""
+}
+
+// Returns the steps of the last build of the current profile and a signature of the log.
+func (app *Application) readNinjaLog() ([]ninja.Edge, string, error) {
+	if app.db.ProfileIndex == -1 || len(app.db.ProfilePath) == 0 {
+		return nil, "", configurationError(errors.New("you must re-configure this project (snake configure)"))
+	}
+
+	logPath := filepath.Join(app.db.ProfilePath, ".ninja_log")
+	file, err := os.Open(logPath)
+
+	if os.IsNotExist(err) {
+		return nil, "", configurationError(fmt.Errorf("no build log found (see 'snake build'): %s", logPath))
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	edges, err := ninja.ReadLog(file)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	signature := ""
+
+	if len(edges) > 0 {
+		signature = fmt.Sprintf("%d:%d:%d:%s", info.Size(), len(edges),
+			ninja.Duration(edges), edges[len(edges)-1].Hash)
+	}
+
+	return edges, signature, nil
+}
+
+// Sum the step durations per target. Steps not belonging to a target are
+// grouped under "(other)".
+func (app *Application) targetTimes(edges []ninja.Edge) (map[string]int, map[string]int) {
+	times := map[string]int{}
+	steps := map[string]int{}
+
+	for _, e := range edges {
+		target := app.outputTarget(e.Outputs[0])
+
+		if len(target) == 0 {
+			target = "(other)"
+		}
+
+		times[target] += e.Duration()
+		steps[target]++
+	}
+
+	return times, steps
+}
+
+// Add the last build of the current profile to the history unless it is
+// already recorded.
+func (app *Application) recordBuild(edges []ninja.Edge, signature string) {
+	exists, profile := app.getCurrentProfile()
+
+	if !exists || len(edges) == 0 {
+		return
+	}
+
+	if app.db.Builds == nil {
+		app.db.Builds = map[string][]BuildRecord{}
+	}
+
+	history := app.db.Builds[profile.Name]
+
+	if len(history) > 0 && history[len(history)-1].Signature == signature {
+		return
+	}
+
+	targets, _ := app.targetTimes(edges)
+
+	history = append(history, BuildRecord{
+		Time:      time.Now().Unix(),
+		Duration:  ninja.Duration(edges),
+		Total:     ninja.Total(edges),
+		Steps:     len(edges),
+		Targets:   targets,
+		Signature: signature,
+	})
+
+	if len(history) > buildHistorySize {
+		history = history[len(history)-buildHistorySize:]
+	}
+
+	app.db.Builds[profile.Name] = history
+	app.storageChanged()
+}
+
+// Record the last build after running the build tool. The statistics are
+// optional so failures are only reported in verbose mode.
+func (app *Application) recordLastBuild() {
+	edges, signature, err := app.readNinjaLog()
+
+	if err == nil {
+		app.recordBuild(edges, signature)
+		err = app.saveStorage()
+	}
+
+	if err != nil && app.verbose {
+		fmt.Fprintln(app.ErrOrStderr(), "warning: unable to record build statistics:", err)
+	}
+}
+
+// Formats milliseconds as seconds.
+func seconds(ms int) string {
+	return fmt.Sprintf("%.2fs", float64(ms)/1000)
+}
+
+// StepInfo is a step of the build for machine-readable output.
+type StepInfo struct {
+	Output   string `json:"output" yaml:"output"`
+	Target   string `json:"target" yaml:"target"`
+	Start    int    `json:"start" yaml:"start"`
+	Duration int    `json:"duration" yaml:"duration"`
+}
+
+// TargetTimeInfo is the time spent building a target.
+type TargetTimeInfo struct {
+	Name     string `json:"name" yaml:"name"`
+	Duration int    `json:"duration" yaml:"duration"`
+	Steps    int    `json:"steps" yaml:"steps"`
+}
+
+// BuildStatsInfo describes the last build for machine-readable output. Times
+// are in milliseconds.
+type BuildStatsInfo struct {
+	Profile      string           `json:"profile" yaml:"profile"`
+	Duration     int              `json:"duration" yaml:"duration"`
+	Total        int              `json:"total" yaml:"total"`
+	Steps        int              `json:"steps" yaml:"steps"`
+	Slowest      []StepInfo       `json:"slowest" yaml:"slowest"`
+	Targets      []TargetTimeInfo `json:"targets" yaml:"targets"`
+	CriticalPath []StepInfo       `json:"critical-path" yaml:"critical-path"`
+	Parallelism  []ninja.Sample   `json:"parallelism" yaml:"parallelism"`
+}
+
+// Returns true if the output is an object file.
+func isObjectFile(output string) bool {
+	ext := filepath.Ext(output)
+	return ext == ".o" || ext == ".obj"
+}
+
+// Returns the source of an object file relative to its target directory.
+func objectSource(output string) string {
+	output = filepath.ToSlash(output)
+
+	if loc := objectDirRegex.FindStringIndex(output); loc != nil {
+		output = output[loc[1]:]
+	}
+
+	return strings.TrimSuffix(output, path.Ext(output))
+}
+
+// Collect the statistics of the last build. Only the first entries of the
+// slowest translation units and targets are kept unless top is 0.
+func (app *Application) buildStats(edges []ninja.Edge, top int) BuildStatsInfo {
+	stats := BuildStatsInfo{
+		Duration:     ninja.Duration(edges),
+		Total:        ninja.Total(edges),
+		Steps:        len(edges),
+		Slowest:      []StepInfo{},
+		Targets:      []TargetTimeInfo{},
+		CriticalPath: []StepInfo{},
+		Parallelism:  ninja.Parallelism(edges, parallelismIntervals),
+	}
+
+	if exists, profile := app.getCurrentProfile(); exists {
+		stats.Profile = profile.Name
+	}
+
+	newStep := func(e ninja.Edge) StepInfo {
+		return StepInfo{
+			Output:   e.Outputs[0],
+			Target:   app.outputTarget(e.Outputs[0]),
+			Start:    e.Start,
+			Duration: e.Duration(),
+		}
+	}
+
+	for _, e := range edges {
+		if isObjectFile(e.Outputs[0]) {
+			stats.Slowest = append(stats.Slowest, newStep(e))
+		}
+	}
+
+	sort.SliceStable(stats.Slowest, func(i, j int) bool {
+		return stats.Slowest[i].Duration > stats.Slowest[j].Duration
+	})
+
+	if top > 0 && len(stats.Slowest) > top {
+		stats.Slowest = stats.Slowest[:top]
+	}
+
+	times, steps := app.targetTimes(edges)
+
+	for name, duration := range times {
+		stats.Targets = append(stats.Targets, TargetTimeInfo{Name: name, Duration: duration, Steps: steps[name]})
+	}
+
+	sort.Slice(stats.Targets, func(i, j int) bool {
+		if stats.Targets[i].Duration != stats.Targets[j].Duration {
+			return stats.Targets[i].Duration > stats.Targets[j].Duration
+		}
+
+		return stats.Targets[i].Name < stats.Targets[j].Name
+	})
+
+	if top > 0 && len(stats.Targets) > top {
+		stats.Targets = stats.Targets[:top]
+	}
+
+	for _, e := range ninja.CriticalPath(edges) {
+		stats.CriticalPath = append(stats.CriticalPath, newStep(e))
+	}
+
+	return stats
+}
+
+// Print the statistics of the last build of the current profile.
+func (app *Application) printBuildStats(top int, traceOut string, format string) error {
+	edges, signature, err := app.readNinjaLog()
+
+	if err != nil {
+		return err
+	}
+
+	if len(edges) == 0 {
+		fmt.Fprintln(app.OutOrStdout(), "No build steps recorded")
+		return nil
+	}
+
+	app.recordBuild(edges, signature)
+
+	if err = app.saveStorage(); err != nil {
+		return err
+	}
+
+	if len(traceOut) > 0 {
+		file, err := os.Create(traceOut)
+
+		if err != nil {
+			return err
+		}
+
+		if err = ninja.WriteChrome(file, edges, app.outputTarget); err != nil {
+			file.Close()
+			return err
+		}
+
+		if err = file.Close(); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(app.ErrOrStderr(), "Trace written to", traceOut)
+	}
+
+	stats := app.buildStats(edges, top)
+
+	return app.printOutput(format, stats, func(w io.Writer) error {
+		parallelism := 0.0
+
+		if stats.Duration > 0 {
+			parallelism = float64(stats.Total) / float64(stats.Duration)
+		}
+
+		fmt.Fprintf(w, "Last build of %s: %d steps in %s (%s of work, average parallelism %.1f)\n",
+			stats.Profile, stats.Steps, seconds(stats.Duration), seconds(stats.Total), parallelism)
+
+		if len(stats.Slowest) > 0 {
+			fmt.Fprintln(w, "\nSlowest translation units:")
+			fmt.Fprintln(w, "\nTIME\tTARGET\tSOURCE")
+
+			for _, s := range stats.Slowest {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", seconds(s.Duration), s.Target, objectSource(s.Output))
+			}
+		}
+
+		fmt.Fprintln(w, "\nTargets:")
+		fmt.Fprintln(w, "\nTIME\t%\tSTEPS\tTARGET")
+
+		for _, t := range stats.Targets {
+			share := 0.0
+
+			if stats.Total > 0 {
+				share = 100 * float64(t.Duration) / float64(stats.Total)
+			}
+
+			fmt.Fprintf(w, "%s\t%.1f%%\t%d\t%s\n", seconds(t.Duration), share, t.Steps, t.Name)
+		}
+
+		length := 0
+
+		for _, s := range stats.CriticalPath {
+			length += s.Duration
+		}
+
+		fmt.Fprintf(w, "\nCritical path (estimated, %s):\n", seconds(length))
+		fmt.Fprintln(w, "\nSTART\tTIME\tOUTPUT")
+
+		for _, s := range stats.CriticalPath {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", seconds(s.Start), seconds(s.Duration), s.Output)
+		}
+
+		fmt.Fprintln(w, "\nParallelism:")
+		fmt.Fprintln(w, "\nINTERVAL\tJOBS\t")
+
+		for _, s := range stats.Parallelism {
+			fmt.Fprintf(w, "%s-%s\t%.1f\t%s\n", seconds(s.Start), seconds(s.End), s.Parallelism,
+				strings.Repeat("#", int(s.Parallelism+0.5)))
+		}
+
+		return nil
+	})
+}
+
+// Print the build history of the current profile and the targets that got
+// slower since the previous build.
+func (app *Application) printBuildHistory(format string) error {
+	exists, profile := app.getCurrentProfile()
+
+	if !exists {
+		return configurationError(errors.New("you must re-configure this project (snake configure)"))
+	}
+
+	// Include the last build if it was not run through Snake.
+	if edges, signature, err := app.readNinjaLog(); err == nil {
+		app.recordBuild(edges, signature)
+
+		if err = app.saveStorage(); err != nil {
+			return err
+		}
+	}
+
+	history := app.db.Builds[profile.Name]
+
+	return app.printOutput(format, history, func(w io.Writer) error {
+		if len(history) == 0 {
+			fmt.Fprintln(w, "No builds recorded for", profile.Name)
+			return nil
+		}
+
+		fmt.Fprintf(w, "Builds of %s:\n\n", profile.Name)
+		fmt.Fprintln(w, "DATE\tDURATION\tWORK\tSTEPS\tCHANGE")
+
+		for i, b := range history {
+			change := "-"
+
+			if i > 0 && history[i-1].Duration > 0 {
+				change = fmt.Sprintf("%+.1f%%", 100*float64(b.Duration-history[i-1].Duration)/
+					float64(history[i-1].Duration))
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", time.Unix(b.Time, 0).Format("2006-01-02 15:04"),
+				seconds(b.Duration), seconds(b.Total), b.Steps, change)
+		}
+
+		if len(history) < 2 {
+			return nil
+		}
+
+		last := history[len(history)-1]
+		names := []string{}
+
+		for name := range last.Targets {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		fmt.Fprintln(w, "\nRegressions since the previous build of each target:")
+		fmt.Fprintln(w, "\nTARGET\tBEFORE\tAFTER\tCHANGE")
+
+		regressions := 0
+
+		for _, name := range names {
+			// Incremental builds do not rebuild every target.
+			for i := len(history) - 2; i >= 0; i-- {
+				before, ok := history[i].Targets[name]
+
+				if !ok {
+					continue
+				}
+
+				after := last.Targets[name]
+
+				if before > 0 && after-before >= regressionMinimum && float64(after-before) >= regressionRatio*float64(before) {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%+.1f%%\n", name, seconds(before), seconds(after),
+						100*float64(after-before)/float64(before))
+					regressions++
+				}
+
+				break
+			}
+		}
+
+		if regressions == 0 {
+			fmt.Fprintln(w, "None")
+		}
+
+		return nil
+	})
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the build history and regressions of the current profile",
+	RunE: func(c *cobra.Command, args []string) error {
+		if err := app.initSlow(); err != nil {
+			return err
+		}
+
+		return app.printBuildHistory(statsOutputFlag)
+	},
+}
+
+var statsBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Analyze the last build of the current profile",
+	RunE: func(c *cobra.Command, args []string) error {
+		if err := app.initSlow(); err != nil {
+			return err
+		}
+
+		return app.printBuildStats(statsTopFlag, statsTraceOutFlag, statsBuildOutputFlag)
+	},
+}
+
+func init() {
+	addOutputFlag(statsCmd, &statsOutputFlag)
+	addOutputFlag(statsBuildCmd, &statsBuildOutputFlag)
+
+	statsBuildCmd.Flags().IntVar(&statsTopFlag,
+		"top", 10,
+		"Number of translation units and targets reported (0 for all)")
+
+	statsBuildCmd.Flags().StringVar(&statsTraceOutFlag,
+		"trace-out", "",
+		"Write the build in the Chrome trace-event format (chrome://tracing, Perfetto)")
+
+	statsCmd.AddCommand(statsBuildCmd)
+}
@@ -7,23 +7,107 @@ package application
 
 import (
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/sumartian-studios/snake/configuration"
 )
 
-func listTargets() error {
-	if app.cfg.Targets == nil {
-		fmt.Println("No targets available")
-		return nil
+// TargetTestInfo describes a test group of a target.
+type TargetTestInfo struct {
+	Name      string   `json:"name" yaml:"name"`
+	Functions []string `json:"functions" yaml:"functions"`
+	Cases     []string `json:"cases" yaml:"cases"`
+	Labels    []string `json:"labels" yaml:"labels"`
+}
+
+// TargetInfo describes a target for machine-readable output.
+type TargetInfo struct {
+	Name        string           `json:"name" yaml:"name"`
+	Description string           `json:"description" yaml:"description"`
+	Type        string           `json:"type" yaml:"type"`
+	Path        string           `json:"path" yaml:"path"`
+	Requirement string           `json:"requirement" yaml:"requirement"`
+	Export      bool             `json:"export" yaml:"export"`
+	Libraries   []string         `json:"libraries" yaml:"libraries"`
+	Tests       []TargetTestInfo `json:"tests" yaml:"tests"`
+	Discover    string           `json:"discover,omitempty" yaml:"discover,omitempty"`
+}
+
+// Collect the information of a target across all of its features.
+func newTargetInfo(t *configuration.Target) TargetInfo {
+	info := TargetInfo{
+		Name:        t.Name,
+		Description: t.Description,
+		Type:        t.Type,
+		Path:        t.Path,
+		Requirement: t.Requirement,
+		Export:      t.Export != nil && *t.Export,
+		Libraries:   []string{},
+		Tests:       []TargetTestInfo{},
+		Discover:    t.Discover,
+	}
+
+	if t.Features == nil {
+		return info
 	}
 
-	targets := *app.cfg.Targets
+	for _, feat := range *t.Features {
+		if feat.Libraries != nil {
+			for _, lib := range *feat.Libraries {
+				info.Libraries = append(info.Libraries, lib.Targets...)
+			}
+		}
 
-	for _, target := range targets {
-		fmt.Println("--", target.Name)
+		if feat.Tests != nil {
+			for _, test := range *feat.Tests {
+				info.Tests = append(info.Tests, TargetTestInfo{
+					Name:      test.Name,
+					Functions: test.Functions,
+					Cases:     test.CaseNames(),
+					Labels:    test.Labels,
+				})
+			}
+		}
 	}
 
-	return nil
+	return info
+}
+
+// Returns the targets of the loaded configuration.
+func (app *Application) Targets() []TargetInfo {
+	targets := []TargetInfo{}
+
+	if app.cfg.Targets != nil {
+		for _, target := range *app.cfg.Targets {
+			targets = append(targets, newTargetInfo(&target))
+		}
+	}
+
+	return targets
+}
+
+var targetsOutputFlag string
+
+func (app *Application) listTargets(format string) error {
+	targets := app.Targets()
+
+	return app.printOutput(format, targets, func(w io.Writer) error {
+		if len(targets) == 0 {
+			fmt.Fprintln(w, "No targets available")
+			return nil
+		}
+
+		fmt.Fprintln(w, "NAME\tTYPE\tPATH\tREQUIREMENT\tLIBRARIES")
+
+		for _, t := range targets {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Name, t.Type, t.Path, t.Requirement,
+				strings.Join(t.Libraries, ", "))
+		}
+
+		return nil
+	})
 }
 
 var listTargetsCmd = &cobra.Command{
@@ -34,6 +118,10 @@ var listTargetsCmd = &cobra.Command{
 			return err
 		}
 
-		return listTargets()
+		return app.listTargets(targetsOutputFlag)
 	},
 }
+
+func init() {
+	addOutputFlag(listTargetsCmd, &targetsOutputFlag)
+}
@@ -6,35 +6,462 @@
 package application
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
-var testCmd = &cobra.Command{
-	Use:                "test",
-	Short:              "Run built unit tests and benchmarks",
-	DisableFlagParsing: true,
-	RunE: func(c *cobra.Command, args []string) error {
-		defer app.timeTrack(time.Now(), "Testing")
+// Options of a test run.
+type TestOptions struct {
+	// Regular expression selecting the tests followed by options passed to CTest.
+	Args []string
+
+	// Only run the tests affected by the changes since this revision.
+	Since string
+
+	// Measure the coverage of the tests. The profile must enable coverage.
+	Coverage bool
+
+	// Reports written after the tests (JUnit or JSON).
+	Reports []TestReport
+
+	// Number of times the failed tests are run again.
+	Retries int
+
+	// Only print the tests with intermittent results.
+	FlakyReport bool
+
+	// Number of tests run in parallel (0 for the CTest default).
+	Parallel int
+
+	// Default timeout of the tests in seconds (0 for the CTest default).
+	Timeout int
+
+	// Run the tests several times (ex. until-fail:10).
+	Repeat string
+
+	// Only run the tests with one of these labels.
+	Labels []string
+
+	// Do not run the tests with one of these labels.
+	ExcludedLabels []string
+
+	// Only run a subset of the selected tests (ex. on one of several CI runners).
+	Shard *TestShard
+
+	// JSON report of a previous run used to balance the shards by duration. The
+	// tests are split by name only without it.
+	ShardDurations string
+}
+
+// Matches the modes of 'ctest --repeat'.
+var testRepeatRegex = regexp.MustCompile(`^(until-fail|until-pass|after-timeout):[1-9][0-9]*$`)
+
+// Returns the CTest arguments filtering the tests by label.
+func (opts *TestOptions) filterArgs() []string {
+	args := []string{}
+
+	if len(opts.Labels) > 0 {
+		args = append(args, "-L", exactRegex(opts.Labels))
+	}
+
+	if len(opts.ExcludedLabels) > 0 {
+		args = append(args, "-LE", exactRegex(opts.ExcludedLabels))
+	}
+
+	return args
+}
+
+// Returns the CTest arguments of the execution options.
+func (opts *TestOptions) ctestArgs() []string {
+	args := opts.filterArgs()
+
+	if opts.Parallel > 0 {
+		args = append(args, "--parallel", strconv.Itoa(opts.Parallel))
+	}
+
+	if opts.Timeout > 0 {
+		args = append(args, "--timeout", strconv.Itoa(opts.Timeout))
+	}
+
+	if len(opts.Repeat) > 0 {
+		args = append(args, "--repeat", opts.Repeat)
+	}
+
+	return args
+}
+
+// Run the tests using CTest.
+func (app *Application) Test(opts TestOptions) error {
+	defer app.timeTrack(time.Now(), "Testing")
+
+	// The configuration is needed for hooks.
+	if err := app.initSlow(); err != nil {
+		return err
+	}
+
+	if opts.FlakyReport {
+		return app.printFlakyReport()
+	}
+
+	args, selector := opts.Args, ""
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		selector, args = app.testSelector(args[0]), args[1:]
+	}
+
+	if len(opts.Since) > 0 {
+		targets, err := app.affectedTargets(opts.Since)
+
+		if err != nil {
+			return err
+		}
+
+		tests := []string{}
+
+		for _, t := range targets {
+			for _, test := range newTargetInfo(t).Tests {
+				tests = append(tests, regexp.QuoteMeta(test.Name))
+			}
+
+			// Discovered tests are prefixed with the target name.
+			if len(t.Discover) > 0 {
+				tests = append(tests, regexp.QuoteMeta(t.Name))
+			}
+		}
+
+		if len(tests) == 0 {
+			fmt.Fprintln(app.OutOrStdout(), "No affected tests since", opts.Since)
+			return nil
+		}
+
+		affected := "^(" + strings.Join(tests, "|") + ")(/|$)"
+
+		// CTest only accepts one expression so the tests selected by the user
+		// are listed and filtered here.
+		if len(selector) > 0 {
+			names, err := app.listTests([]string{"-R", selector})
+
+			if err != nil {
+				return err
+			}
+
+			matching := []string{}
+			r := regexp.MustCompile(affected)
+
+			for _, name := range names {
+				if r.MatchString(name) {
+					matching = append(matching, name)
+				}
+			}
+
+			if len(matching) == 0 {
+				fmt.Fprintf(app.OutOrStdout(), "No affected tests matching %s since %s\n", opts.Args[0], opts.Since)
+				return nil
+			}
+
+			affected = exactRegex(matching)
+		}
+
+		selector = affected
+	}
+
+	if len(selector) == 0 {
+		selector = ".*"
+	}
+
+	args = append([]string{selector}, args...)
+
+	exists, profile := app.getCurrentProfile()
+	profileName := ""
+
+	if exists {
+		profileName = profile.Name
+	}
+
+	if opts.Shard != nil {
+		// The arguments forwarded to CTest (ex. -E slow) also select the tests.
+		filter := append(append([]string{"-R", args[0]}, args[1:]...), opts.filterArgs()...)
+		names, err := app.listTests(filter)
+
+		if err != nil {
+			return err
+		}
+
+		durations := map[string]float64{}
+
+		if len(opts.ShardDurations) > 0 {
+			if durations, err = readTestDurations(opts.ShardDurations); err != nil {
+				return err
+			}
+		}
+
+		names = shardTests(names, durations, *opts.Shard)
+
+		if len(names) == 0 {
+			fmt.Fprintf(app.OutOrStdout(), "No tests in shard %d/%d\n", opts.Shard.Index, opts.Shard.Count)
+			return nil
+		}
+
+		fmt.Fprintf(app.OutOrStdout(), "Running %d tests of shard %d/%d\n", len(names), opts.Shard.Index, opts.Shard.Count)
+
+		args[0] = exactRegex(names)
+	}
+
+	args = append(args, opts.ctestArgs()...)
+
+	if opts.Coverage {
+		if !exists {
+			return configurationError(errors.New("you must re-configure this project (snake configure)"))
+		}
 
-		if err := app.initFast(); err != nil {
+		if !profile.Coverage {
+			return configurationError(fmt.Errorf("profile %s does not enable coverage (set 'coverage: true')", profile.Name))
+		}
+
+		// Only the coverage of this run is reported.
+		if err := app.resetCoverage(); err != nil {
 			return err
 		}
+	}
+
+	if err := app.runHooks("pre-test"); err != nil {
+		return err
+	}
+
+	result, testErr, err := app.runCTest(profileName, args)
+
+	if err != nil {
+		return err
+	}
 
-		if len(args) == 0 {
-			args = append(args, ".*")
-		} else if args[0][0] == '-' {
-			args = append([]string{".*"}, args...)
+	// Only the failed tests are run again.
+	for attempt := 1; result != nil && attempt <= opts.Retries; attempt++ {
+		failed := result.failed()
+
+		if len(failed) == 0 {
+			break
 		}
 
-		opts := append([]string{"--test-dir", app.db.ProfilePath,
-			"--output-on-failure", "-R"}, args...)
+		fmt.Fprintf(app.OutOrStdout(), "\nRetrying %d failed tests (%d/%d)\n", len(failed), attempt, opts.Retries)
+
+		var retry *TestRunResult
+
+		retry, testErr, err = app.runCTest(profileName, append([]string{exactRegex(failed)}, args[1:]...))
 
-		if err := app.launch("ctest", opts...); err != nil {
+		if err != nil {
 			return err
 		}
 
+		if retry == nil {
+			break
+		}
+
+		result.merge(retry)
+	}
+
+	// CTest does not write the results when no test was run.
+	if result != nil {
+		if exists {
+			app.recordTestResults(profile.Name, result)
+
+			if err := app.saveStorage(); err != nil {
+				return err
+			}
+		}
+
+		if err := app.writeTestReports(result, opts.Reports); err != nil {
+			return err
+		}
+
+		testErr = app.checkTestResults(result, testErr)
+	} else if len(opts.Reports) > 0 && testErr == nil && app.context().Err() == nil {
+		fmt.Fprintln(app.ErrOrStderr(), "warning: no test results to report")
+	}
+
+	// Failing tests still produce coverage data.
+	if opts.Coverage && app.context().Err() == nil {
+		if err := app.reportCoverage(profileName); err != nil {
+			return err
+		}
+	}
+
+	if testErr != nil {
+		return testErr
+	}
+
+	return app.runHooks("post-test")
+}
+
+// Returns a CTest regular expression matching exactly one of the names (ex. test
+// names or labels).
+func exactRegex(names []string) string {
+	quoted := []string{}
+
+	for _, name := range names {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// Run CTest with a test selector followed by CTest options. Returns the results
+// (nil if CTest did not write them), the error of CTest, and the error reading
+// the results.
+func (app *Application) runCTest(profile string, args []string) (*TestRunResult, error, error) {
+	// Results of a previous run must not be reported.
+	if err := os.Remove(app.ctestResultsPath()); err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	ctestArgs := []string{"--test-dir", app.db.ProfilePath, "--output-on-failure",
+		"--output-junit", app.ctestResultsPath(), "-R"}
+
+	start := time.Now()
+	testErr := app.launch("ctest", append(ctestArgs, args...)...)
+
+	if app.context().Err() != nil {
+		return nil, testErr, nil
+	}
+
+	result, err := app.readTestResults(profile, start, time.Since(start))
+
+	if os.IsNotExist(err) {
+		return nil, testErr, nil
+	}
+
+	return result, testErr, err
+}
+
+// Returns the CTest regular expression selecting the tests of a group or a single
+// function (ex. group/function or target/discovered-case). Other selectors are used as regular expressions.
+func (app *Application) testSelector(selector string) string {
+	for _, t := range app.Targets() {
+		for _, test := range t.Tests {
+			if test.Name == selector {
+				return "^" + regexp.QuoteMeta(selector) + "(/|$)"
+			}
+
+			for _, name := range test.Cases {
+				if name == selector {
+					return "^" + regexp.QuoteMeta(selector) + "$"
+				}
+			}
+		}
+	}
+
+	for _, name := range app.discoveredTestNames() {
+		if name == selector {
+			return "^" + regexp.QuoteMeta(selector) + "$"
+		}
+	}
+
+	return selector
+}
+
+// Split the arguments of 'snake test' into Snake options and CTest arguments.
+func parseTestArgs(args []string) (TestOptions, error) {
+	var opts TestOptions
+
+	affected, since := false, "HEAD"
+
+	setParallel := func(v string) error {
+		n, err := strconv.Atoi(v)
+
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of parallel tests: %s", v)
+		}
+
+		opts.Parallel = n
 		return nil
+	}
+
+	flags := []forwardedFlag{
+		{"--affected", false, func(string) error { affected = true; return nil }},
+		{"--since", true, func(v string) error { affected, since = true, v; return nil }},
+		{"--coverage", false, func(string) error { opts.Coverage = true; return nil }},
+		{"--retries", true, func(v string) error {
+			n, err := strconv.Atoi(v)
+
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid number of retries: %s", v)
+			}
+
+			opts.Retries = n
+			return nil
+		}},
+		{"--flaky-report", false, func(string) error { opts.FlakyReport = true; return nil }},
+		{"-j", true, setParallel},
+		{"--parallel", true, setParallel},
+		{"--timeout", true, func(v string) error {
+			n, err := strconv.Atoi(v)
+
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid timeout (seconds): %s", v)
+			}
+
+			opts.Timeout = n
+			return nil
+		}},
+		{"--repeat", true, func(v string) error {
+			if !testRepeatRegex.MatchString(v) {
+				return fmt.Errorf("expected until-fail:N, until-pass:N, or after-timeout:N: %s", v)
+			}
+
+			opts.Repeat = v
+			return nil
+		}},
+		{"--label", true, func(v string) error { opts.Labels = append(opts.Labels, v); return nil }},
+		{"--exclude-label", true, func(v string) error { opts.ExcludedLabels = append(opts.ExcludedLabels, v); return nil }},
+		{"--shard", true, func(v string) error {
+			shard, err := parseTestShard(v)
+			opts.Shard = &shard
+			return err
+		}},
+		{"--shard-durations", true, func(v string) error { opts.ShardDurations = v; return nil }},
+		{"--report", true, func(v string) error {
+			report, err := parseTestReport(v)
+			opts.Reports = append(opts.Reports, report)
+			return err
+		}},
+	}
+
+	args, err := extractFlags(args, flags)
+
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Args = args
+
+	if len(opts.ShardDurations) > 0 && opts.Shard == nil {
+		return opts, usageError(errors.New("--shard-durations requires --shard"))
+	}
+
+	if affected {
+		opts.Since = since
+	}
+
+	return opts, nil
+}
+
+var testCmd = &cobra.Command{
+	Use:                "test",
+	Short:              "Run built unit tests and benchmarks",
+	DisableFlagParsing: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		opts, err := parseTestArgs(args)
+
+		if err != nil {
+			return err
+		}
+
+		return app.Test(opts)
 	},
 }
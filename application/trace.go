@@ -0,0 +1,130 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sumartian-studios/snake/trace"
+)
+
+var traceTopFlag int
+var traceOutFlag string
+
+// Returns a path relative to the project or Snake directory when possible.
+func (app *Application) shortPath(file string) string {
+	for _, dir := range []string{app.snakeDir, app.rootDir} {
+		if rel, err := filepath.Rel(dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+
+	return file
+}
+
+// Read the trace of the last configuration.
+func (app *Application) readTrace() ([]trace.Event, error) {
+	file, err := os.Open(filepath.Join(app.db.ProfilePath, "cmake.trace"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return trace.Read(file)
+}
+
+// Write the traced CMake commands in the Chrome trace-event format.
+func (app *Application) writeChromeTrace(events []trace.Event, path string) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	if err = trace.WriteChrome(file, events, app.shortPath); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(app.OutOrStdout(), "Trace written to", path)
+
+	return nil
+}
+
+// Print where the time is spent in the traced CMake commands. Only the first
+// entries of each section are printed unless top is 0.
+func (app *Application) printTraceReport(events []trace.Event, top int) error {
+	total := trace.Duration(events)
+	out := app.OutOrStdout()
+
+	fmt.Fprintf(out, "\nTraced %d commands in %.2fms\n", len(events), total*1000)
+
+	if total <= 0 {
+		return nil
+	}
+
+	sections := []struct {
+		title     string
+		entries   []trace.Entry
+		inclusive bool
+	}{
+		{"Targets", trace.ByTarget(events), true},
+		{"Snake macros", trace.ByMacro(events), true},
+		{"Commands", trace.ByCommand(events), false},
+		{"Call sites", trace.ByLocation(events, app.shortPath), false},
+	}
+
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+
+		trace.Sort(section.entries, section.inclusive)
+
+		entries := section.entries
+
+		if top > 0 && len(entries) > top {
+			entries = entries[:top]
+		}
+
+		fmt.Fprintf(out, "\n%s (sorted by %s time):\n\n", section.title,
+			map[bool]string{true: "inclusive", false: "exclusive"}[section.inclusive])
+
+		writer := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+		fmt.Fprintln(writer, "INCLUSIVE\t%\tEXCLUSIVE\t%\tCALLS\tNAME")
+
+		for _, e := range entries {
+			exclusive := fmt.Sprintf("%.2fms\t%.1f%%", e.Exclusive*1000, 100*e.Exclusive/total)
+
+			// Targets span many commands so only their inclusive time is meaningful.
+			if section.title == "Targets" {
+				exclusive = "-\t-"
+			}
+
+			fmt.Fprintf(writer, "%.2fms\t%.1f%%\t%s\t%d\t%s\n",
+				e.Inclusive*1000, 100*e.Inclusive/total, exclusive, e.Calls, e.Name)
+		}
+
+		writer.Flush()
+
+		if len(entries) < len(section.entries) {
+			fmt.Fprintf(out, "... %d more (see --trace-top)\n", len(section.entries)-len(entries))
+		}
+	}
+
+	return nil
+}
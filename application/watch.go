@@ -0,0 +1,295 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sumartian-studios/snake/utilities"
+)
+
+var watchRunFlag string
+var watchTestFlag string
+var watchDebounceFlag time.Duration
+
+// Characters that make a resource path a regular expression.
+var resourcePatternChars = regexp.MustCompile(`[*+?()\[\]{}|^$\\]`)
+
+// Returns the configuration file, target directories, and resource files.
+func (app *Application) watchedPaths() []string {
+	paths := []string{app.configPath}
+
+	if app.cfg.Targets == nil {
+		return paths
+	}
+
+	for _, t := range *app.cfg.Targets {
+		paths = append(paths, filepath.Join(app.rootDir, t.Path))
+
+		if t.Features == nil {
+			continue
+		}
+
+		for _, feat := range *t.Features {
+			if feat.Resources == nil {
+				continue
+			}
+
+			for _, resource := range *feat.Resources {
+				for _, pattern := range resource.Files {
+					pattern = strings.ReplaceAll(pattern, "${CMAKE_SOURCE_DIR}/", "")
+					pattern = strings.ReplaceAll(pattern, "${TARGET_SOURCE_DIR}", t.Path)
+
+					// Watch the deepest directory that is not part of the expression.
+					for resourcePatternChars.MatchString(pattern) {
+						pattern = filepath.Dir(pattern)
+					}
+
+					paths = append(paths, filepath.Join(app.rootDir, pattern))
+				}
+			}
+		}
+	}
+
+	return paths
+}
+
+// Returns the directories written by Snake. They are not watched even if a target
+// contains them (ex. "path: .") otherwise every build would trigger another one.
+func (app *Application) unwatchedPaths() []string {
+	return []string{app.snakeDir, filepath.Join(app.rootDir, ".snake")}
+}
+
+// Returns every file inside the watched paths except the excluded directories.
+// Used to detect added or removed sources since they are only globbed during
+// configuration.
+func listFiles(paths []string, excluded []string) map[string]bool {
+	files := map[string]bool{}
+
+	for _, p := range paths {
+		filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if d.IsDir() {
+				for _, e := range excluded {
+					if path == e {
+						return filepath.SkipDir
+					}
+				}
+
+				return nil
+			}
+
+			files[path] = true
+
+			return nil
+		})
+	}
+
+	return files
+}
+
+// Returns true for temporary files written by editors.
+func isEditorFile(path string) bool {
+	name := filepath.Base(path)
+
+	return strings.HasPrefix(name, ".#") || strings.HasSuffix(name, "~") ||
+		strings.HasSuffix(name, ".swp") || strings.HasSuffix(name, ".swx") || name == "4913"
+}
+
+// Run the minimal pipeline for a batch of changed files (relative to the root directory).
+func (app *Application) watchCycle(changed []string, structural bool, run string, test string) {
+	start := time.Now()
+	steps := []string{}
+
+	var err error
+
+	yamlChanged := false
+
+	for _, file := range changed {
+		if file == ".snake.yml" {
+			yamlChanged = true
+		}
+	}
+
+	if yamlChanged {
+		steps = append(steps, "generate")
+		err = app.run("generate")
+	}
+
+	if err == nil && (yamlChanged || structural) {
+		steps = append(steps, "configure")
+		err = app.run("configure")
+	}
+
+	if err == nil {
+		names := []string{}
+
+		for _, t := range app.targetsAffectedBy(changed) {
+			if t.Type != "header-library" {
+				names = append(names, t.Name)
+			}
+		}
+
+		if len(names) > 0 {
+			steps = append(steps, "build "+strings.Join(names, " "))
+			err = app.run(append([]string{"build"}, names...)...)
+		}
+	}
+
+	if err == nil && len(test) > 0 {
+		steps = append(steps, "test "+test)
+		err = app.run("test", test)
+	}
+
+	if err == nil && len(run) > 0 {
+		steps = append(steps, "run "+run)
+		err = app.run("run", run)
+	}
+
+	result := "\033[0;32mok\033[0m"
+
+	if err != nil {
+		result = fmt.Sprintf("\033[0;31mfailed\033[0m (%v)", err)
+	}
+
+	if len(steps) == 0 {
+		steps = append(steps, "nothing to do")
+	}
+
+	fmt.Fprintf(app.OutOrStdout(), "\033[0;90m[%s]\033[0m %d changed: %s: %s in %s\n", start.Format("15:04:05"),
+		len(changed), strings.Join(steps, ", "), result, time.Since(start).Round(time.Millisecond))
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Rebuild affected targets when files change",
+	RunE: func(c *cobra.Command, args []string) error {
+		if err := app.initSlow(); err != nil {
+			return err
+		}
+
+		// Flags are reset every time a command runs so we keep a copy.
+		run, test, debounce := watchRunFlag, watchTestFlag, watchDebounceFlag
+
+		watcher, err := utilities.NewWatcher()
+
+		if err != nil {
+			return err
+		}
+
+		defer watcher.Close()
+
+		excluded := app.unwatchedPaths()
+
+		for _, p := range excluded {
+			if err := watcher.Exclude(p); err != nil {
+				return err
+			}
+		}
+
+		addWatches := func() []string {
+			paths := []string{}
+
+			for _, p := range app.watchedPaths() {
+				if err := watcher.Add(p); err == nil {
+					paths = append(paths, p)
+				} else if app.verbose {
+					fmt.Fprintln(app.ErrOrStderr(), "warning: unable to watch:", err)
+				}
+			}
+
+			return paths
+		}
+
+		paths := addWatches()
+		files := listFiles(paths, excluded)
+
+		// Cancelled by SIGINT or SIGTERM.
+		ctx := app.context()
+
+		fmt.Fprintf(app.OutOrStdout(), "Watching %d paths (Ctrl+C to stop)...\n", len(paths))
+
+		pending := map[string]bool{}
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return nil
+				}
+
+				return err
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return nil
+				}
+
+				if isEditorFile(e.Path) {
+					continue
+				}
+
+				if rel, err := filepath.Rel(app.rootDir, e.Path); err == nil {
+					pending[filepath.ToSlash(rel)] = true
+				}
+
+				// Wait for a burst of saves to settle down.
+				timer.Reset(debounce)
+			case <-timer.C:
+				changed := []string{}
+
+				for file := range pending {
+					changed = append(changed, file)
+				}
+
+				sort.Strings(changed)
+				pending = map[string]bool{}
+
+				current := listFiles(paths, excluded)
+				structural := len(current) != len(files)
+
+				for file := range current {
+					if !files[file] {
+						structural = true
+					}
+				}
+
+				app.watchCycle(changed, structural, run, test)
+
+				// Targets or resources may have changed.
+				paths = addWatches()
+				files = listFiles(paths, excluded)
+			}
+		}
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&watchRunFlag,
+		"run", "",
+		"Run an executable target after each successful build")
+
+	watchCmd.Flags().StringVar(&watchTestFlag,
+		"test", "",
+		"Run the tests matching the expression after each successful build")
+
+	watchCmd.Flags().DurationVar(&watchDebounceFlag,
+		"debounce", 300*time.Millisecond,
+		"Time to wait for changes to settle before rebuilding")
+}
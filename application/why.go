@@ -0,0 +1,129 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package application
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/sumartian-studios/snake/graph"
+)
+
+// WhyInfo explains why a library or target is part of the build.
+type WhyInfo struct {
+	Name     string `json:"name" yaml:"name"`
+	Kind     string `json:"kind" yaml:"kind"`
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Package  string `json:"package,omitempty" yaml:"package,omitempty"`
+
+	// The conditions used to enable the target or fetch the package.
+	Conditions []string `json:"conditions" yaml:"conditions"`
+
+	Paths [][]*graph.Edge `json:"paths" yaml:"paths"`
+}
+
+// Returns the conditions gating a library the same way the generator accumulates
+// them; every target and feature condition linking it directly.
+func directConditions(g *graph.Graph, name string) []string {
+	seen := map[string]bool{}
+	conditions := []string{}
+
+	for _, e := range g.Incoming(name) {
+		for _, c := range e.Conditions {
+			if !seen[c] {
+				seen[c] = true
+				conditions = append(conditions, c)
+			}
+		}
+	}
+
+	return conditions
+}
+
+var whyOutputFlag string
+
+func (app *Application) explain(name string, format string) error {
+	g := graph.New(app.cfg)
+	n := g.Node(name)
+
+	if n == nil {
+		return usageError(fmt.Errorf("unknown target or library: %s", name))
+	}
+
+	conditions := directConditions(g, name)
+
+	// Targets are enabled by their own requirement.
+	if n.Kind == graph.KindTarget {
+		conditions = []string{n.Requirement}
+	}
+
+	info := WhyInfo{
+		Name:       n.Name,
+		Kind:       n.Kind,
+		Provider:   n.Provider,
+		Package:    n.Package,
+		Conditions: conditions,
+		Paths:      g.Paths(name),
+	}
+
+	return app.printOutput(format, info, func(w io.Writer) error {
+		if info.Provider != "" {
+			fmt.Fprintf(w, "%s (%s: %s)\n", info.Name, info.Provider, info.Package)
+		} else {
+			fmt.Fprintf(w, "%s (%s)\n", info.Name, info.Kind)
+		}
+
+		if len(info.Paths) == 0 {
+			fmt.Fprintln(w, "Not used by any target")
+			return nil
+		}
+
+		if len(info.Conditions) > 0 {
+			fmt.Fprintf(w, "Enabled when: (%s)\n", strings.Join(info.Conditions, ") AND ("))
+		}
+
+		for _, path := range info.Paths {
+			names := []string{path[0].From}
+
+			for _, e := range path {
+				names = append(names, e.To)
+			}
+
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, strings.Join(names, " -> "))
+
+			for _, e := range path {
+				fmt.Fprintf(w, "  %s -> %s\t[%s]\tif (%s)\n",
+					e.From, e.To, e.Link, strings.Join(e.Conditions, ") AND ("))
+			}
+		}
+
+		return nil
+	})
+}
+
+var whyCmd = &cobra.Command{
+	Use:   "why library",
+	Short: "Explain which targets pull in a library or target",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return usageError(errors.New("you must specify one library or target"))
+		}
+
+		if err := app.initSlow(); err != nil {
+			return err
+		}
+
+		return app.explain(args[0], whyOutputFlag)
+	},
+}
+
+func init() {
+	addOutputFlag(whyCmd, &whyOutputFlag)
+}
@@ -5,8 +5,17 @@
 
 package cmake
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 func Quote(s string) string {
 	return fmt.Sprintf("\"%s\"", s)
 }
+
+// Escape backslashes and quotes so the string can be used as a quoted argument.
+// Variable references are still evaluated by CMake.
+func Escape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(s)
+}
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strings"
 
 	"github.com/sumartian-studios/snake/configuration"
@@ -44,6 +45,12 @@ type Generator struct {
 
 		// Map of conditional aliases (ex. and becomes AND)
 		IfAliasMap map[string]bool
+
+		// Map of script and target names. Used to tell script dependencies from files.
+		TargetMap map[string]bool
+
+		// Warning policy inherited by the targets.
+		Warnings *configuration.Warnings
 	}
 }
 
@@ -186,8 +193,8 @@ func (g *Generator) AddTargetFeature(t *configuration.Target, feat *configuratio
 
 	if feat.Tests != nil {
 		tests := *feat.Tests
-		for _, test := range tests {
-			g.Call("add_test", "NAME", Quote(test.Name), "COMMAND", t.Name+" "+strings.Join(test.Functions, " "))
+		for i := range tests {
+			g.AddTest(t, &tests[i])
 		}
 	}
 
@@ -260,6 +267,11 @@ func (g *Generator) AddTarget(t *configuration.Target, i int, count int) {
 	g.Call("snake_init_target", t.Name, Quote(t.Path),
 		g.Context.defaultLinkType, t.Type, Quote(t.Description), export)
 
+	// Interface libraries are not compiled.
+	if t.Type != "header-library" {
+		g.AddTargetWarnings(t)
+	}
+
 	if t.Features != nil {
 		features := *t.Features
 
@@ -268,33 +280,169 @@ func (g *Generator) AddTarget(t *configuration.Target, i int, count int) {
 		}
 	}
 
+	if t.Type == "test" && len(t.Discover) > 0 {
+		g.Call("snake_discover_tests", t.Name)
+	}
+
 	g.Call("snake_fini_target", t.Name)
 	g.Call("else")
 	g.Call("print_dim_status", "\"${TARGET_STATUS} (disabled)\"")
 	g.Call("endif")
 }
 
-func (g *Generator) AddScript(s *configuration.Script) {
-	if len(s.Commands) > 0 {
-		a := []string{s.Name, "WORKING_DIRECTORY", "${CMAKE_SOURCE_DIR}"}
+// Returns a path relative to the project root unless it is absolute.
+func sourcePath(p string) string {
+	if strings.HasPrefix(p, "/") || strings.HasPrefix(p, "${") {
+		return p
+	}
+
+	return "${CMAKE_SOURCE_DIR}/" + p
+}
+
+// Returns the COMMAND arguments of a script command.
+func scriptCommand(o *configuration.ScriptOptions, command string) []string {
+	// The shell reads the arguments from the environment of the build.
+	command = strings.ReplaceAll(command, "{{args}}", "$SNAKE_SCRIPT_ARGS")
+
+	a := []string{"COMMAND"}
+
+	if len(o.Env) > 0 {
+		keys := []string{}
+
+		for k := range o.Env {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		a = append(a, "${CMAKE_COMMAND}", "-E", "env")
+
+		for _, k := range keys {
+			a = append(a, Quote(Escape(k+"="+o.Env[k])))
+		}
+
+		a = append(a, "--")
+	}
+
+	if o.Shell == "sh" || o.Shell == "bash" {
+		return append(a, o.Shell, "-c", Quote(Escape(command)))
+	}
+
+	return append(a, command)
+}
+
+func (g *Generator) addScriptTarget(s *configuration.Script, o *configuration.ScriptOptions) {
+	if len(o.Commands) == 0 {
+		return
+	}
+
+	dir := "${CMAKE_SOURCE_DIR}"
+
+	if len(o.Cwd) > 0 {
+		dir = sourcePath(o.Cwd)
+	}
+
+	commands := []string{}
+
+	for _, exec := range o.Commands {
+		if len(exec) > 0 {
+			commands = append(commands, scriptCommand(o, exec)...)
+		}
+	}
 
-		if s.Products != nil {
-			a = append(a, "BYPRODUCTS", Quote(strings.Join(*s.Products, " ")))
+	// Arguments must reach the program (or the explicit shell) unchanged.
+	if len(o.Shell) > 0 {
+		commands = append(commands, "VERBATIM")
+	}
+
+	files, scripts, products := []string{}, []string{}, []string{}
+
+	if s.Requires != nil {
+		for _, r := range *s.Requires {
+			if g.Context.TargetMap[r] {
+				scripts = append(scripts, r)
+			} else {
+				files = append(files, Quote(sourcePath(r)))
+			}
 		}
+	}
 
-		if s.Requires != nil {
-			a = append(a, "DEPENDS", Quote(strings.Join(*s.Requires, " ")))
+	if s.Products != nil {
+		for _, p := range *s.Products {
+			products = append(products, Quote(sourcePath(p)))
 		}
+	}
 
-		for _, exec := range s.Commands {
-			if len(exec) > 0 {
-				a = append(a, "COMMAND")
-				a = append(a, exec)
+	if len(products) > 0 && (len(files) > 0 || len(s.Inputs) > 0) {
+		// The products are only re-generated when the inputs change.
+		if len(s.Inputs) > 0 {
+			patterns := []string{}
+
+			for _, i := range s.Inputs {
+				patterns = append(patterns, Quote(sourcePath(i)))
 			}
+
+			g.Call("file", append([]string{"GLOB_RECURSE", "SNAKE_SCRIPT_INPUTS", "CONFIGURE_DEPENDS"}, patterns...)...)
+			files = append(files, "${SNAKE_SCRIPT_INPUTS}")
+		}
+
+		a := append([]string{"OUTPUT"}, products...)
+		a = append(a, "DEPENDS")
+		a = append(a, files...)
+		a = append(a, "WORKING_DIRECTORY", dir)
+
+		g.Call("add_custom_command", append(a, commands...)...)
+		g.Call("add_custom_target", append([]string{s.Name, "DEPENDS"}, products...)...)
+	} else {
+		a := []string{s.Name, "WORKING_DIRECTORY", dir}
+
+		if len(products) > 0 {
+			a = append(a, "BYPRODUCTS")
+			a = append(a, products...)
+		}
+
+		if len(files) > 0 {
+			a = append(a, "DEPENDS")
+			a = append(a, files...)
+		}
+
+		g.Call("add_custom_target", append(a, commands...)...)
+	}
+
+	if len(scripts) > 0 {
+		g.Call("add_dependencies", append([]string{s.Name}, scripts...)...)
+	}
+}
+
+func (g *Generator) AddScript(s *configuration.Script) {
+	if len(s.Profiles) == 0 {
+		g.addScriptTarget(s, &s.ScriptOptions)
+		return
+	}
+
+	profiles := []string{}
+
+	for profile := range s.Profiles {
+		profiles = append(profiles, profile)
+	}
+
+	sort.Strings(profiles)
+
+	// The profile is only known at configuration time.
+	for i, profile := range profiles {
+		if i == 0 {
+			g.Call("if", "SNAKE_PROFILE", "STREQUAL", Quote(profile))
+		} else {
+			g.Call("elseif", "SNAKE_PROFILE", "STREQUAL", Quote(profile))
 		}
 
-		g.Call("add_custom_target", a...)
+		options := s.ForProfile(profile)
+		g.addScriptTarget(s, &options)
 	}
+
+	g.Call("else")
+	g.addScriptTarget(s, &s.ScriptOptions)
+	g.Call("endif")
 }
 
 func (g *Generator) AddGlobalFeature(feat *configuration.Feature) {
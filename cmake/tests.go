@@ -0,0 +1,89 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package cmake
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sumartian-studios/snake/configuration"
+)
+
+// Returns a quoted CMake list. Semicolons inside the elements are escaped.
+func quoteList(elements []string) string {
+	escaped := make([]string, 0, len(elements))
+
+	for _, e := range elements {
+		escaped = append(escaped, strings.ReplaceAll(e, ";", "\\;"))
+	}
+
+	return Quote(Escape(strings.Join(escaped, ";")))
+}
+
+// Register the functions of a test with CTest. Each function is a separate test
+// so CTest can filter and run them in parallel.
+func (g *Generator) AddTest(t *configuration.Target, test *configuration.Test) {
+	names := test.CaseNames()
+
+	for i, name := range names {
+		a := []string{"NAME", Quote(name), "COMMAND", t.Name}
+
+		if len(test.Functions) > 0 {
+			a = append(a, Quote(Escape(test.Functions[i])))
+		}
+
+		if test.WorkingDirectory != nil {
+			a = append(a, "WORKING_DIRECTORY", Quote(Escape(*test.WorkingDirectory)))
+		}
+
+		g.Call("add_test", a...)
+	}
+
+	properties := []string{}
+
+	if len(test.Labels) > 0 {
+		properties = append(properties, "LABELS", quoteList(test.Labels))
+	}
+
+	if test.Timeout != nil {
+		properties = append(properties, "TIMEOUT", fmt.Sprint(*test.Timeout))
+	}
+
+	if len(test.Environment) > 0 {
+		keys := []string{}
+
+		for k := range test.Environment {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		env := []string{}
+
+		for _, k := range keys {
+			env = append(env, k+"="+test.Environment[k])
+		}
+
+		properties = append(properties, "ENVIRONMENT", quoteList(env))
+	}
+
+	if test.WillFail {
+		properties = append(properties, "WILL_FAIL", "on")
+	}
+
+	if len(properties) == 0 {
+		return
+	}
+
+	a := []string{}
+
+	for _, name := range names {
+		a = append(a, Quote(name))
+	}
+
+	g.Call("set_tests_properties", append(append(a, "PROPERTIES"), properties...)...)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package cmake
+
+import (
+	"strings"
+
+	"github.com/sumartian-studios/snake/configuration"
+)
+
+// Flags of each warning level for compilers accepting GCC options (GCC, Clang)
+// and for compilers accepting MSVC options (MSVC, clang-cl).
+var warningLevels = map[string][2][]string{
+	"none":     {{"-w"}, {"/W0"}},
+	"default":  {{}, {}},
+	"strict":   {{"-Wall", "-Wextra"}, {"/W4"}},
+	"pedantic": {{"-Wall", "-Wextra", "-Wpedantic", "-Wshadow", "-Wconversion", "-Wsign-conversion"}, {"/W4", "/permissive-"}},
+}
+
+// Returns true if the warning level is supported. An empty level is the default level.
+func ValidWarningLevel(level string) bool {
+	_, ok := warningLevels[level]
+	return ok || len(level) == 0
+}
+
+// Returns true if the warning is a MSVC warning number (ex. 4100 or C4100).
+func isWarningNumber(s string) bool {
+	s = strings.TrimPrefix(strings.ToUpper(s), "C")
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return len(s) > 0
+}
+
+// Returns the GCC and MSVC flags of a warning policy.
+func warningFlags(w *configuration.Warnings) ([]string, []string) {
+	levels := warningLevels[w.Level]
+	gnu := append([]string{}, levels[0]...)
+	msvc := append([]string{}, levels[1]...)
+
+	if w.AsErrors != nil && *w.AsErrors {
+		gnu = append(gnu, "-Werror")
+		msvc = append(msvc, "/WX")
+	}
+
+	for _, d := range w.Disabled {
+		if isWarningNumber(d) {
+			msvc = append(msvc, "/wd"+strings.TrimPrefix(strings.ToUpper(d), "C"))
+		} else {
+			gnu = append(gnu, "-Wno-"+strings.TrimPrefix(strings.TrimPrefix(d, "-Wno-"), "-W"))
+		}
+	}
+
+	return gnu, msvc
+}
+
+// Add the compile options of the target warning policy. The options depend on the
+// compiler so they are selected with generator expressions.
+func (g *Generator) AddTargetWarnings(t *configuration.Target) {
+	w := configuration.MergeWarnings(g.Context.Warnings, t.Warnings)
+	gnu, msvc := warningFlags(&w)
+	options := []string{}
+
+	if len(gnu) > 0 {
+		options = append(options, Quote("$<$<CXX_COMPILER_FRONTEND_VARIANT:GNU>:"+strings.Join(gnu, ";")+">"))
+	}
+
+	if len(msvc) > 0 {
+		options = append(options, Quote("$<$<CXX_COMPILER_FRONTEND_VARIANT:MSVC>:"+strings.Join(msvc, ";")+">"))
+	}
+
+	if len(options) > 0 {
+		g.Call("target_compile_options", append([]string{t.Name, "PRIVATE"}, options...)...)
+	}
+}
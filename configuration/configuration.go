@@ -42,6 +42,16 @@ type Configuration struct {
 	// List o runnable scripts.
 	Scripts *[]Script `yaml:"Scripts"`
 
+	// Scripts executed before or after Snake commands.
+	Hooks *Hooks `yaml:"Hooks"`
+
+	// Warning policy of every target. Targets can override it.
+	Warnings *Warnings `yaml:"Warnings"`
+
+	// List of tests (ex. group/function), test groups, or targets with discovered
+	// tests whose failures do not fail 'snake test'. They still run.
+	Quarantine []string `yaml:"Quarantine"`
+
 	// List of build profiles.
 	Profiles []Profile `yaml:"Profiles"`
 
@@ -0,0 +1,70 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package configuration
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lifecycle stages with hooks.
+var HookStages = []string{
+	"pre-configure", "post-configure", "pre-build", "post-build", "pre-test", "post-test", "pre-package",
+}
+
+type Hook struct {
+	// The name of the script to run.
+	Script string `yaml:"script" jsonschema:"required"`
+
+	// What to do when the script fails; "abort" (default) stops the command and
+	// "warn" only prints a warning.
+	OnFailure string `yaml:"on-failure"`
+}
+
+type Hooks struct {
+	// Scripts to run before configuring the build system.
+	PreConfigure []Hook `yaml:"pre-configure"`
+
+	// Scripts to run after configuring the build system.
+	PostConfigure []Hook `yaml:"post-configure"`
+
+	// Scripts to run before building.
+	PreBuild []Hook `yaml:"pre-build"`
+
+	// Scripts to run after a successful build.
+	PostBuild []Hook `yaml:"post-build"`
+
+	// Scripts to run before testing.
+	PreTest []Hook `yaml:"pre-test"`
+
+	// Scripts to run after the tests passed.
+	PostTest []Hook `yaml:"post-test"`
+
+	// Scripts to run before packaging.
+	PrePackage []Hook `yaml:"pre-package"`
+}
+
+// Rejects unknown stages which would otherwise be ignored.
+func (h *Hooks) UnmarshalYAML(value *yaml.Node) error {
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		stage, known := value.Content[i], false
+
+		for _, s := range HookStages {
+			known = known || s == stage.Value
+		}
+
+		if !known {
+			return fmt.Errorf("line %d: invalid hook stage (%s): %s", stage.Line, strings.Join(HookStages, ", "),
+				stage.Value)
+		}
+	}
+
+	type hooks Hooks
+
+	return value.Decode((*hooks)(h))
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package configuration
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestHooks(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   *Hooks
+		err    string
+	}{
+		{
+			name:   "stages",
+			config: "Hooks:\n  pre-build:\n    - script: gen\n  post-test:\n    - script: report\n      on-failure: warn\n",
+			want: &Hooks{
+				PreBuild: []Hook{{Script: "gen"}},
+				PostTest: []Hook{{Script: "report", OnFailure: "warn"}},
+			},
+		},
+		{
+			name:   "no hooks",
+			config: "Version: 1.0.0\n",
+		},
+		{
+			name:   "unknown stage",
+			config: "Hooks:\n  pre-build: []\n  pre-bild:\n    - script: gen\n",
+			err: "line 3: invalid hook stage (pre-configure, post-configure, pre-build, post-build, pre-test, " +
+				"post-test, pre-package): pre-bild",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cfg Configuration
+
+			err := yaml.Unmarshal([]byte(test.config), &cfg)
+
+			if len(test.err) > 0 {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %s", err, test.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(cfg.Hooks, test.want) {
+				t.Errorf("got %+v, want %+v", cfg.Hooks, test.want)
+			}
+		})
+	}
+}
@@ -32,4 +32,10 @@ type Profile struct {
 
 	// List of compiler flags.
 	CompileFlags []string `yaml:"flags.compile"`
+
+	// List of sanitizers (address, undefined, thread, memory, or leak).
+	Sanitizers []string `yaml:"sanitizers"`
+
+	// Instrument the targets to measure the test coverage (see 'snake test --coverage').
+	Coverage bool `yaml:"coverage"`
 }
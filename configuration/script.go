@@ -5,19 +5,81 @@
 
 package configuration
 
+type ScriptOptions struct {
+	// List of commands this script will run. The "{{args}}" placeholder is replaced
+	// by the arguments passed to 'snake run'.
+	Commands []string `yaml:"commands"`
+
+	// Map of environment variables set for every command.
+	Env map[string]string `yaml:"env"`
+
+	// Optional working directory relative to the project root.
+	Cwd string `yaml:"cwd"`
+
+	// The shell used to run commands (sh, bash, or none). When set to "none" the
+	// commands are split into arguments and executed directly. Defaults to the
+	// platform shell.
+	Shell string `yaml:"shell"`
+}
+
 type Script struct {
+	ScriptOptions `yaml:",inline"`
+
 	// Script name.
 	Name string `yaml:"name"`
 
 	// Script description.
 	Description string `yaml:"description"`
 
-	// List of commands this script will run.
-	Commands []string `yaml:"commands"`
-
-	// Optional dependencies.
+	// Optional dependencies. These can be other scripts or files.
 	Requires *[]string `yaml:"requires"`
 
 	// Optional products (i.e. files this script will produce).
 	Products *[]string `yaml:"products"`
+
+	// Optional list of input files (glob patterns matched recursively). The script
+	// is skipped when its products are newer than every input.
+	Inputs []string `yaml:"inputs"`
+
+	// Map of options overridden by profile id.
+	Profiles map[string]ScriptOptions `yaml:"profiles"`
+}
+
+// Returns the script options for a profile.
+func (s *Script) ForProfile(profile string) ScriptOptions {
+	options := s.ScriptOptions
+
+	override, ok := s.Profiles[profile]
+
+	if !ok {
+		return options
+	}
+
+	if len(override.Commands) > 0 {
+		options.Commands = override.Commands
+	}
+
+	if len(override.Env) > 0 {
+		env := map[string]string{}
+
+		for k, v := range s.Env {
+			env[k] = v
+		}
+
+		for k, v := range override.Env {
+			env[k] = v
+		}
+
+		options.Env = env
+	}
+
+	if len(override.Cwd) > 0 {
+		options.Cwd = override.Cwd
+	}
+
+	if len(override.Shell) > 0 {
+		options.Shell = override.Shell
+	}
+
+	return options
 }
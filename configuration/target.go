@@ -30,6 +30,13 @@ type Target struct {
 	// is set to the absolute path and can be used by your configuration.
 	Path string `yaml:"path" jsonschema:"required"`
 
+	// The test framework used to discover the test cases after each build: "qttest",
+	// "gtest", or "catch2". Only active when target type is "test".
+	Discover string `yaml:"discover"`
+
+	// Warning policy of the target. Unset fields are inherited from the global policy.
+	Warnings *Warnings `yaml:"warnings"`
+
 	// List of conditional features acquired by this target. These
 	// are executed in the order they are provided.
 	Features *[]TargetFeature `yaml:"features"`
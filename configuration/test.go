@@ -9,6 +9,43 @@ type Test struct {
 	// Name of this test.
 	Name string `yaml:"name"`
 
-	// List of function names.
+	// List of function names. Each function is registered as a CTest test named
+	// "<name>/<function>".
 	Functions []string `yaml:"functions"`
+
+	// List of CTest labels.
+	Labels []string `yaml:"labels"`
+
+	// Timeout of each function (in seconds).
+	Timeout *int `yaml:"timeout"`
+
+	// Map of environment variables set while running the functions.
+	Environment map[string]string `yaml:"environment"`
+
+	// The directory in which the functions run.
+	WorkingDirectory *string `yaml:"working-directory"`
+
+	// The functions are expected to fail.
+	WillFail bool `yaml:"will-fail"`
+}
+
+// Returns the CTest name of a function of this test.
+func (t *Test) CaseName(function string) string {
+	return t.Name + "/" + function
+}
+
+// Returns the CTest names of this test. A test without functions runs the whole
+// target under its own name.
+func (t *Test) CaseNames() []string {
+	if len(t.Functions) == 0 {
+		return []string{t.Name}
+	}
+
+	names := make([]string, 0, len(t.Functions))
+
+	for _, f := range t.Functions {
+		names = append(names, t.CaseName(f))
+	}
+
+	return names
 }
@@ -0,0 +1,45 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package configuration
+
+type Warnings struct {
+	// The warning level: "none", "default", "strict", or "pedantic". The compiler
+	// defaults are used when set to "default".
+	Level string `yaml:"level"`
+
+	// Treat warnings as errors.
+	AsErrors *bool `yaml:"as-errors"`
+
+	// List of disabled warnings. Names (ex. unused-parameter) are passed to GCC and
+	// Clang while numbers (ex. 4100) are passed to MSVC.
+	Disabled []string `yaml:"disabled"`
+}
+
+// Returns the policy of a target inheriting the unset fields from the global
+// policy. Both policies can be nil.
+func MergeWarnings(global *Warnings, target *Warnings) Warnings {
+	var w Warnings
+
+	if global != nil {
+		w.Level = global.Level
+		w.AsErrors = global.AsErrors
+		w.Disabled = append(w.Disabled, global.Disabled...)
+	}
+
+	if target != nil {
+		if len(target.Level) > 0 {
+			w.Level = target.Level
+		}
+
+		if target.AsErrors != nil {
+			w.AsErrors = target.AsErrors
+		}
+
+		w.Disabled = append(w.Disabled, target.Disabled...)
+	}
+
+	return w
+}
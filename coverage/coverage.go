@@ -0,0 +1,167 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package coverage
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// File is the line coverage of a source file.
+type File struct {
+	// Path of the source file.
+	Path string
+
+	// Execution count by line number. Lines without code are absent.
+	Lines map[int]int64
+}
+
+// Returns the number of executed lines and the number of lines with code.
+func (f *File) Counts() (int, int) {
+	covered := 0
+
+	for _, count := range f.Lines {
+		if count > 0 {
+			covered++
+		}
+	}
+
+	return covered, len(f.Lines)
+}
+
+// Returns the line numbers in increasing order.
+func (f *File) LineNumbers() []int {
+	lines := []int{}
+
+	for line := range f.Lines {
+		lines = append(lines, line)
+	}
+
+	sort.Ints(lines)
+
+	return lines
+}
+
+// Report is the line coverage of a set of source files.
+type Report struct {
+	Files map[string]*File
+}
+
+// Create an empty report.
+func NewReport() *Report {
+	return &Report{Files: map[string]*File{}}
+}
+
+// Add execution counts to a file. Counts of the same line are summed since
+// headers are compiled in several translation units.
+func (r *Report) Add(path string, lines map[int]int64) {
+	f, ok := r.Files[path]
+
+	if !ok {
+		f = &File{Path: path, Lines: map[int]int64{}}
+		r.Files[path] = f
+	}
+
+	for line, count := range lines {
+		f.Lines[line] += count
+	}
+}
+
+// Add the files of another report.
+func (r *Report) Merge(other *Report) {
+	for path, f := range other.Files {
+		r.Add(path, f.Lines)
+	}
+}
+
+// Returns the number of executed lines and the number of lines with code.
+func (r *Report) Counts() (int, int) {
+	covered, total := 0, 0
+
+	for _, f := range r.Files {
+		c, t := f.Counts()
+		covered += c
+		total += t
+	}
+
+	return covered, total
+}
+
+// Returns the files sorted by path.
+func (r *Report) SortedFiles() []*File {
+	files := []*File{}
+
+	for _, f := range r.Files {
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files
+}
+
+// Returns the ratio of executed lines (1 if there is no code).
+func Rate(covered int, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+
+	return float64(covered) / float64(total)
+}
+
+// Read a .gcov file produced by gcov or llvm-cov gcov. Returns the source path
+// recorded in the file and the execution count of each line with code.
+func ReadGcov(r io.Reader) (string, map[int]int64, error) {
+	source := ""
+	lines := map[int]int64{}
+	scanner := bufio.NewScanner(r)
+
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+
+		if len(fields) < 3 {
+			continue
+		}
+
+		count := strings.TrimSpace(fields[0])
+		line, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+
+		if err != nil {
+			continue
+		}
+
+		if line == 0 {
+			if strings.HasPrefix(fields[2], "Source:") {
+				source = strings.TrimPrefix(fields[2], "Source:")
+			}
+
+			continue
+		}
+
+		switch {
+		case count == "-":
+			// No code on this line.
+		case strings.HasPrefix(count, "#") || strings.HasPrefix(count, "="):
+			lines[line] += 0
+		default:
+			// Lines with unexecuted blocks are suffixed with '*'.
+			n, err := strconv.ParseInt(strings.TrimSuffix(count, "*"), 10, 64)
+
+			if err == nil {
+				lines[line] += n
+			}
+		}
+	}
+
+	return source, lines, scanner.Err()
+}
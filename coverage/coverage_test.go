@@ -0,0 +1,88 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package coverage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadGcov(t *testing.T) {
+	tests := []struct {
+		name   string
+		gcov   string
+		source string
+		lines  map[int]int64
+	}{
+		{
+			name: "gcc",
+			gcov: `        -:    0:Source:/project/src/main.cc
+        -:    0:Graph:main.gcno
+        -:    1:#include <cstdio>
+        5:    2:int main() {
+    #####:    3:  std::puts("never");
+        1:    4:  return 0;
+        -:    5:}
+`,
+			source: "/project/src/main.cc",
+			lines:  map[int]int64{2: 5, 3: 0, 4: 1},
+		},
+		{
+			name: "exceptional and partially executed lines",
+			gcov: `        -:    0:Source:src/a.cc
+    =====:    7:  throw std::runtime_error("x");
+       3*:    8:  if (a && b) {
+`,
+			source: "src/a.cc",
+			lines:  map[int]int64{7: 0, 8: 3},
+		},
+		{
+			name: "function summaries and separators are skipped",
+			gcov: `        -:    0:Source:src/t.h
+function _Z1fIiET_S0_ called 2 returned 100% blocks executed 100%
+        2:   10:  return x;
+------------------
+_Z1fIiET_S0_:
+------------------
+`,
+			source: "src/t.h",
+			lines:  map[int]int64{10: 2},
+		},
+		{
+			name: "llvm-cov with colons in the source",
+			gcov: `        -:    0:Source:C:/project/main.cc
+        1:    1:int main() { return f(::x); }
+`,
+			source: "C:/project/main.cc",
+			lines:  map[int]int64{1: 1},
+		},
+		{
+			name:   "no lines",
+			gcov:   "",
+			source: "",
+			lines:  map[int]int64{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, lines, err := ReadGcov(strings.NewReader(test.gcov))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if source != test.source {
+				t.Errorf("got source %q, want %q", source, test.source)
+			}
+
+			if !reflect.DeepEqual(lines, test.lines) {
+				t.Errorf("got lines %v, want %v", lines, test.lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package coverage
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Write the report as an lcov tracefile. Relative paths are resolved from the
+// source directory.
+func (r *Report) WriteLcov(w io.Writer, name string, source string) error {
+	writer := bufio.NewWriter(w)
+
+	for _, f := range r.SortedFiles() {
+		path := f.Path
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(source, path)
+		}
+
+		fmt.Fprintf(writer, "TN:%s\nSF:%s\n", name, path)
+
+		for _, line := range f.LineNumbers() {
+			fmt.Fprintf(writer, "DA:%d,%d\n", line, f.Lines[line])
+		}
+
+		covered, total := f.Counts()
+		fmt.Fprintf(writer, "LH:%d\nLF:%d\nend_of_record\n", covered, total)
+	}
+
+	return writer.Flush()
+}
+
+type coberturaLine struct {
+	Number int   `xml:"number,attr"`
+	Hits   int64 `xml:"hits,attr"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        float64            `xml:"line-rate,attr"`
+	BranchRate      float64            `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+// Returns the names of the reports in increasing order.
+func sortedNames(reports map[string]*Report) []string {
+	names := []string{}
+
+	for name := range reports {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Write a Cobertura XML report with one package per target. Paths must be relative
+// to the source directory.
+func WriteCobertura(w io.Writer, targets map[string]*Report, source string) error {
+	total := NewReport()
+
+	for _, r := range targets {
+		total.Merge(r)
+	}
+
+	covered, valid := total.Counts()
+
+	out := coberturaCoverage{
+		LineRate:     Rate(covered, valid),
+		LinesCovered: covered,
+		LinesValid:   valid,
+		Version:      "snake",
+		Timestamp:    time.Now().Unix(),
+		Sources:      []string{source},
+	}
+
+	for _, name := range sortedNames(targets) {
+		r := targets[name]
+		c, v := r.Counts()
+		pkg := coberturaPackage{Name: name, LineRate: Rate(c, v)}
+
+		for _, f := range r.SortedFiles() {
+			c, v := f.Counts()
+			class := coberturaClass{
+				Name:     filepath.ToSlash(f.Path),
+				Filename: filepath.ToSlash(f.Path),
+				LineRate: Rate(c, v),
+			}
+
+			for _, line := range f.LineNumbers() {
+				class.Lines = append(class.Lines, coberturaLine{Number: line, Hits: f.Lines[line]})
+			}
+
+			pkg.Classes = append(pkg.Classes, class)
+		}
+
+		out.Packages = append(out.Packages, pkg)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(out); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+const htmlStyle = `body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { padding: 0.2em 1em; text-align: left; border-bottom: 1px solid #ddd; }
+pre { margin: 0; }
+.hit { background: #dfd; }
+.miss { background: #fdd; }
+.count { color: #888; text-align: right; }`
+
+// Write an HTML report with the coverage per target and the annotated sources.
+// Relative paths are read from the source directory.
+func WriteHTML(w io.Writer, targets map[string]*Report, source string, title string) error {
+	writer := bufio.NewWriter(w)
+	total := NewReport()
+
+	for _, r := range targets {
+		total.Merge(r)
+	}
+
+	row := func(name string, covered int, valid int) {
+		fmt.Fprintf(writer, "<tr><td>%s</td><td>%.1f%%</td><td>%d / %d</td></tr>\n",
+			name, 100*Rate(covered, valid), covered, valid)
+	}
+
+	fmt.Fprintf(writer, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title><style>%s</style></head><body>\n",
+		html.EscapeString(title), htmlStyle)
+	fmt.Fprintf(writer, "<h1>%s</h1>\n", html.EscapeString(title))
+
+	covered, valid := total.Counts()
+	fmt.Fprintf(writer, "<p>%.1f%% of %d lines covered.</p>\n", 100*Rate(covered, valid), valid)
+
+	fmt.Fprintln(writer, "<h2>Targets</h2>\n<table><tr><th>Target</th><th>Lines</th><th></th></tr>")
+
+	for _, name := range sortedNames(targets) {
+		c, v := targets[name].Counts()
+		row(html.EscapeString(name), c, v)
+	}
+
+	fmt.Fprintln(writer, "</table>\n<h2>Files</h2>\n<table><tr><th>File</th><th>Lines</th><th></th></tr>")
+
+	files := total.SortedFiles()
+
+	for i, f := range files {
+		c, v := f.Counts()
+		row(fmt.Sprintf("<a href=\"#f%d\">%s</a>", i, html.EscapeString(f.Path)), c, v)
+	}
+
+	fmt.Fprintln(writer, "</table>")
+
+	for i, f := range files {
+		path := f.Path
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(source, path)
+		}
+
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			continue
+		}
+
+		fmt.Fprintf(writer, "<h3 id=\"f%d\">%s</h3>\n<table>\n", i, html.EscapeString(f.Path))
+
+		for n, text := range strings.Split(string(data), "\n") {
+			class, count := "", ""
+
+			if hits, ok := f.Lines[n+1]; ok {
+				class, count = "miss", "0"
+
+				if hits > 0 {
+					class, count = "hit", fmt.Sprint(hits)
+				}
+			}
+
+			fmt.Fprintf(writer, "<tr class=\"%s\"><td class=\"count\">%d</td><td class=\"count\">%s</td><td><pre>%s</pre></td></tr>\n",
+				class, n+1, count, html.EscapeString(text))
+		}
+
+		fmt.Fprintln(writer, "</table>")
+	}
+
+	fmt.Fprintln(writer, "</body></html>")
+
+	return writer.Flush()
+}
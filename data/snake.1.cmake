@@ -233,6 +233,34 @@ endif()
 add_compile_options(${SNAKE_GLOBAL_COMPILE_OPTIONS})
 add_link_options(${SNAKE_GLOBAL_LINKER_OPTIONS})
 
+# Enable sanitizers
+# -------------------------------------------------------------------------------------------------------
+if(SNAKE_SANITIZERS)
+  if(MSVC)
+    if(NOT SNAKE_SANITIZERS STREQUAL "address")
+      message(FATAL_ERROR "MSVC only supports the address sanitizer: ${SNAKE_SANITIZERS}")
+    endif()
+    add_compile_options(/fsanitize=address)
+  else()
+    if("memory" IN_LIST SNAKE_SANITIZERS AND NOT CMAKE_CXX_COMPILER_ID MATCHES "Clang")
+      message(FATAL_ERROR "The memory sanitizer requires Clang")
+    endif()
+    list(JOIN SNAKE_SANITIZERS "," SNAKE_SANITIZERS_FLAG)
+    add_compile_options(-fsanitize=${SNAKE_SANITIZERS_FLAG} -fno-omit-frame-pointer)
+    add_link_options(-fsanitize=${SNAKE_SANITIZERS_FLAG})
+  endif()
+endif()
+
+# Enable coverage (gcov format for both GCC and Clang)
+# -------------------------------------------------------------------------------------------------------
+if(SNAKE_COVERAGE)
+  if(NOT CMAKE_CXX_COMPILER_ID MATCHES "GNU|Clang")
+    message(FATAL_ERROR "Coverage requires GCC or Clang")
+  endif()
+  add_compile_options(--coverage -fno-inline)
+  add_link_options(--coverage)
+endif()
+
 # Enable IPO
 # -------------------------------------------------------------------------------------------------------
 if(CMAKE_INTERPROCEDURAL_OPTIMIZATION)
@@ -415,6 +415,20 @@ macro(snake_import_plugin TARGET PLUGIN)
   endif()
 endmacro()
 
+# Register the test cases discovered by Snake after each build. The path of the
+# test binary is written for Snake and the discovered tests are included by CTest.
+# -------------------------------------------------------------------------------------------------------
+macro(snake_discover_tests TARGET)
+  set(SNAKE_DISCOVER_FILE "${CMAKE_BINARY_DIR}/snake.discover/${TARGET}")
+
+  file(GENERATE OUTPUT "${SNAKE_DISCOVER_FILE}.path" CONTENT "$<TARGET_FILE:${TARGET}>")
+  file(WRITE "${SNAKE_DISCOVER_FILE}.include.cmake"
+       "if(EXISTS \"${SNAKE_DISCOVER_FILE}.cmake\")\n  include(\"${SNAKE_DISCOVER_FILE}.cmake\")\nendif()\n"
+  )
+
+  set_property(DIRECTORY APPEND PROPERTY TEST_INCLUDE_FILES "${SNAKE_DISCOVER_FILE}.include.cmake")
+endmacro()
+
 # Generate a pkg-config module.
 # -------------------------------------------------------------------------------------------------------
 macro(snake_fetch_pkg NAME)
@@ -0,0 +1,202 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package diagnostics
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Diagnostic is an error or a warning reported by a compiler or a linker.
+type Diagnostic struct {
+	// The file containing the problem.
+	File string `json:"file"`
+
+	// The line of the problem (0 if unknown).
+	Line int `json:"line"`
+
+	// The column of the problem (0 if unknown).
+	Column int `json:"column"`
+
+	// One of "error" or "warning".
+	Severity string `json:"severity"`
+
+	// The message without the location and the flag.
+	Message string `json:"message"`
+
+	// The warning flag controlling the diagnostic (ex. -Wunused-variable).
+	Flag string `json:"flag,omitempty"`
+
+	// The build output being produced when the diagnostic was reported.
+	Output string `json:"output,omitempty"`
+
+	// The target being built when the diagnostic was reported.
+	Target string `json:"target,omitempty"`
+
+	// Notes attached to the diagnostic.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// Matches a GCC or Clang diagnostic (file:line:col: severity: message [-Wflag]).
+var diagnosticRegex = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)? (fatal error|error|warning|note): (.*?)(?: \[(-W[^\]]+)\])?$`)
+
+// Matches a linker diagnostic (ex. ld: error: message).
+var linkerRegex = regexp.MustCompile(`^(\S*(?:ld|collect2|lld)(?:\.\w+)?(?:\.exe)?): (?:(fatal error|error|warning): )?(.*)$`)
+
+// Matches a Ninja status line (ex. [1/10] Building CXX object path/to/file.o).
+var statusRegex = regexp.MustCompile(`^\[\d+/\d+\] .* (\S+)$`)
+
+// Matches ANSI escape sequences (colors).
+var escapeRegex = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// Normalizes a warning flag (ex. -Werror=unused-variable to -Wunused-variable).
+func normalizeFlag(flag string) string {
+	if i := strings.LastIndex(flag, ","); i != -1 {
+		flag = flag[i+1:]
+	}
+
+	return strings.Replace(flag, "-Werror=", "-W", 1)
+}
+
+// Collector is a writer parsing build output line by line. It is safe to use
+// from several goroutines.
+type Collector struct {
+	mutex       sync.Mutex
+	buffer      []byte
+	output      string
+	seen        map[string]bool
+	diagnostics []Diagnostic
+	last        int
+}
+
+// Create a new collector.
+func NewCollector() *Collector {
+	return &Collector{seen: map[string]bool{}, last: -1}
+}
+
+func (c *Collector) Write(data []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.buffer = append(c.buffer, data...)
+
+	for {
+		i := bytes.IndexByte(c.buffer, '\n')
+
+		if i == -1 {
+			break
+		}
+
+		c.parse(string(c.buffer[:i]))
+		c.buffer = c.buffer[i+1:]
+	}
+
+	return len(data), nil
+}
+
+// Parse a line of build output.
+func (c *Collector) parse(line string) {
+	line = strings.TrimRight(escapeRegex.ReplaceAllString(line, ""), "\r")
+
+	if m := statusRegex.FindStringSubmatch(line); m != nil {
+		c.output = m[1]
+		return
+	}
+
+	if strings.HasPrefix(line, "FAILED: ") {
+		c.output = strings.Fields(strings.TrimPrefix(line, "FAILED: ") + " ?")[0]
+		return
+	}
+
+	var d Diagnostic
+
+	linker := linkerRegex.FindStringSubmatch(line)
+
+	if m := diagnosticRegex.FindStringSubmatch(line); m != nil {
+		d.File = m[1]
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+		d.Severity = m[4]
+		d.Message = m[5]
+		d.Flag = normalizeFlag(m[6])
+	} else if linker != nil && len(linker[2]) > 0 {
+		d.File = linker[1]
+		d.Severity = linker[2]
+		d.Message = linker[3]
+	} else if i := strings.Index(line, ": undefined reference to "); i != -1 {
+		// The location is the object file (ex. /usr/bin/ld: main.o:(.text+0x5)).
+		file := line[:i]
+
+		if linker != nil {
+			file = strings.TrimPrefix(file, linker[1]+": ")
+		}
+
+		file, _, _ = strings.Cut(file, ":(")
+
+		d.File = file
+		d.Severity = "error"
+		d.Message = line[i+2:]
+	} else {
+		return
+	}
+
+	if d.Severity == "note" {
+		if c.last != -1 {
+			c.diagnostics[c.last].Notes = append(c.diagnostics[c.last].Notes, line)
+		}
+
+		return
+	}
+
+	if d.Severity == "fatal error" {
+		d.Severity = "error"
+	}
+
+	// Warnings in headers are reported by every translation unit including them.
+	key := strings.Join([]string{d.File, strconv.Itoa(d.Line), strconv.Itoa(d.Column), d.Severity, d.Message}, ":")
+
+	if c.seen[key] {
+		c.last = -1
+		return
+	}
+
+	c.seen[key] = true
+
+	d.Output = c.output
+	c.last = len(c.diagnostics)
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+// Returns the diagnostics collected so far.
+func (c *Collector) Diagnostics() []Diagnostic {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.buffer) > 0 {
+		c.parse(string(c.buffer))
+		c.buffer = nil
+	}
+
+	return append([]Diagnostic{}, c.diagnostics...)
+}
+
+// Returns the number of errors and warnings.
+func Count(diagnostics []Diagnostic) (int, int) {
+	errors, warnings := 0, 0
+
+	for _, d := range diagnostics {
+		if d.Severity == "error" {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+
+	return errors, warnings
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package diagnostics
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCollector(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Diagnostic
+	}{
+		{
+			name:   "gcc warning",
+			output: "[1/3] Building CXX object src/CMakeFiles/app.dir/main.cc.o\nsrc/main.cc:4:9: warning: unused variable 'x' [-Wunused-variable]\n",
+			want: []Diagnostic{{
+				File: "src/main.cc", Line: 4, Column: 9, Severity: "warning",
+				Message: "unused variable 'x'", Flag: "-Wunused-variable",
+				Output: "src/CMakeFiles/app.dir/main.cc.o",
+			}},
+		},
+		{
+			name:   "gcc error promoted from a warning",
+			output: "src/main.cc:4:9: error: unused variable 'x' [-Werror=unused-variable]\n",
+			want: []Diagnostic{{
+				File: "src/main.cc", Line: 4, Column: 9, Severity: "error",
+				Message: "unused variable 'x'", Flag: "-Wunused-variable",
+			}},
+		},
+		{
+			name:   "clang colored error without column",
+			output: "\x1b[1msrc/a.h:12: \x1b[0;1;31merror: \x1b[0mexpected ';' after class\x1b[0m\r\n",
+			want: []Diagnostic{{
+				File: "src/a.h", Line: 12, Severity: "error", Message: "expected ';' after class",
+			}},
+		},
+		{
+			name:   "fatal error",
+			output: "src/main.cc:1:10: fatal error: missing.h: No such file or directory\n",
+			want: []Diagnostic{{
+				File: "src/main.cc", Line: 1, Column: 10, Severity: "error",
+				Message: "missing.h: No such file or directory",
+			}},
+		},
+		{
+			name:   "notes are attached to the previous diagnostic",
+			output: "src/a.cc:3:5: error: no matching function for call to 'f'\nsrc/a.h:1:6: note: candidate function not viable\n",
+			want: []Diagnostic{{
+				File: "src/a.cc", Line: 3, Column: 5, Severity: "error",
+				Message: "no matching function for call to 'f'",
+				Notes:   []string{"src/a.h:1:6: note: candidate function not viable"},
+			}},
+		},
+		{
+			name:   "duplicates from headers are reported once",
+			output: "src/a.h:2:1: warning: unused function 'g' [-Wunused-function]\nsrc/a.h:2:1: warning: unused function 'g' [-Wunused-function]\n",
+			want: []Diagnostic{{
+				File: "src/a.h", Line: 2, Column: 1, Severity: "warning",
+				Message: "unused function 'g'", Flag: "-Wunused-function",
+			}},
+		},
+		{
+			name:   "lld error",
+			output: "FAILED: bin/app\nld.lld: error: undefined symbol: foo()\n",
+			want: []Diagnostic{{
+				File: "ld.lld", Severity: "error", Message: "undefined symbol: foo()", Output: "bin/app",
+			}},
+		},
+		{
+			name:   "ld undefined reference",
+			output: "/usr/bin/ld: CMakeFiles/app.dir/main.cc.o:(.text+0x5): undefined reference to `foo()'\n",
+			want: []Diagnostic{{
+				File: "CMakeFiles/app.dir/main.cc.o", Severity: "error", Message: "undefined reference to `foo()'",
+			}},
+		},
+		{
+			name:   "unterminated last line",
+			output: "src/main.cc:7:3: warning: comparison of integers of different signs [-Wsign-compare]",
+			want: []Diagnostic{{
+				File: "src/main.cc", Line: 7, Column: 3, Severity: "warning",
+				Message: "comparison of integers of different signs", Flag: "-Wsign-compare",
+			}},
+		},
+		{
+			name:   "other output",
+			output: "ninja: build stopped: subcommand failed.\ncollect2: ld returned 1 exit status\n",
+			want:   []Diagnostic{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewCollector()
+
+			fmt.Fprint(c, test.output)
+
+			if got := c.Diagnostics(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
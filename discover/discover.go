@@ -0,0 +1,139 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package discover
+
+import (
+	"bufio"
+	"sort"
+	"strings"
+)
+
+// Framework describes how to list and run the test cases of a test binary.
+type Framework struct {
+	// Arguments printing the test cases of the binary.
+	List []string
+
+	// Returns the test cases printed by the list mode.
+	Parse func(output string) []string
+
+	// Returns the arguments running a single test case.
+	Run func(name string) []string
+}
+
+// Supported test frameworks by name.
+var Frameworks = map[string]*Framework{
+	"qttest": {
+		List:  []string{"-functions"},
+		Parse: parseQtTest,
+		Run:   func(name string) []string { return []string{name} },
+	},
+	"gtest": {
+		List:  []string{"--gtest_list_tests"},
+		Parse: parseGoogleTest,
+		Run:   func(name string) []string { return []string{"--gtest_filter=" + name} },
+	},
+	"catch2": {
+		List:  []string{"--list-tests", "--verbosity", "quiet"},
+		Parse: parseCatch2,
+		Run:   func(name string) []string { return []string{catch2Escaper.Replace(name)} },
+	},
+}
+
+// Returns the names of the supported frameworks in increasing order.
+func Names() []string {
+	names := []string{}
+
+	for name := range Frameworks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Returns the non-empty lines of the output without the trailing spaces.
+func lines(output string) []string {
+	list := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), " \t\r"); len(line) > 0 {
+			list = append(list, line)
+		}
+	}
+
+	return list
+}
+
+// Parse the output of 'test -functions'. Each line is a function followed by "()".
+func parseQtTest(output string) []string {
+	cases := []string{}
+
+	for _, line := range lines(output) {
+		if name := strings.TrimSpace(line); strings.HasSuffix(name, "()") {
+			cases = append(cases, strings.TrimSuffix(name, "()"))
+		}
+	}
+
+	return cases
+}
+
+// Parse the output of 'test --gtest_list_tests'. Test suites end with a dot and
+// are followed by their indented tests. Parameters are printed as comments.
+func parseGoogleTest(output string) []string {
+	cases := []string{}
+	suite := ""
+
+	for _, line := range lines(output) {
+		name, _, _ := strings.Cut(line, "  #")
+
+		if !strings.HasPrefix(name, " ") {
+			// Other lines are printed by main() (ex. "Running main() from gtest_main.cc").
+			suite = ""
+
+			if strings.HasSuffix(name, ".") {
+				suite = name
+			}
+
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+
+		// Disabled tests are listed but never run.
+		if len(suite) == 0 || strings.HasPrefix(suite, "DISABLED_") || strings.HasPrefix(name, "DISABLED_") {
+			continue
+		}
+
+		cases = append(cases, suite+name)
+	}
+
+	return cases
+}
+
+// Parse the output of 'test --list-tests --verbosity quiet'. Catch2 v3 prints one
+// test case per line while Catch2 v2 prints a header, the indented test cases
+// followed by their tags, and a count.
+func parseCatch2(output string) []string {
+	cases := []string{}
+	list := lines(output)
+
+	if len(list) > 0 && strings.HasSuffix(list[0], ":") {
+		for _, line := range list[1:] {
+			if strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "   ") {
+				cases = append(cases, strings.TrimSpace(line))
+			}
+		}
+
+		return cases
+	}
+
+	return append(cases, list...)
+}
+
+// Escapes the characters with a special meaning in Catch2 test specs.
+var catch2Escaper = strings.NewReplacer(`\`, `\\`, `,`, `\,`, `[`, `\[`, `]`, `\]`)
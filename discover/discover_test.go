@@ -0,0 +1,97 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package discover
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		framework string
+		output    string
+		want      []string
+	}{
+		{
+			name:      "qttest",
+			framework: "qttest",
+			output:    "parse()\nformat()\nbenchmarkParse()\n",
+			want:      []string{"parse", "format", "benchmarkParse"},
+		},
+		{
+			name:      "qttest with warnings",
+			framework: "qttest",
+			output:    "QStandardPaths: XDG_RUNTIME_DIR not set\nparse()\r\n",
+			want:      []string{"parse"},
+		},
+		{
+			name:      "gtest",
+			framework: "gtest",
+			output:    "Running main() from gtest_main.cc\nMath.\n  Add\n  Sub\nString.\n  Split\n",
+			want:      []string{"Math.Add", "Math.Sub", "String.Split"},
+		},
+		{
+			name:      "gtest parameterized and typed",
+			framework: "gtest",
+			output:    "Values/Param.  # TypeParam = int\n  Run/0  # GetParam() = 1\n  Run/1  # GetParam() = 2\n",
+			want:      []string{"Values/Param.Run/0", "Values/Param.Run/1"},
+		},
+		{
+			name:      "gtest disabled",
+			framework: "gtest",
+			output:    "DISABLED_Slow.\n  Run\nFast.\n  Run\n  DISABLED_Skip\n",
+			want:      []string{"Fast.Run"},
+		},
+		{
+			name:      "catch2 v3",
+			framework: "catch2",
+			output:    "vectors can be sized\nstrings, with commas [and brackets]\n",
+			want:      []string{"vectors can be sized", "strings, with commas [and brackets]"},
+		},
+		{
+			name:      "catch2 v2",
+			framework: "catch2",
+			output:    "All available test cases:\n  vectors can be sized\n      [vector]\n  maps are sorted\n      [map][slow]\n2 test cases\n",
+			want:      []string{"vectors can be sized", "maps are sorted"},
+		},
+		{
+			name:      "empty",
+			framework: "catch2",
+			output:    "\n",
+			want:      []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Frameworks[test.framework].Parse(test.output); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		framework string
+		name      string
+		want      []string
+	}{
+		{"qttest", "parse", []string{"parse"}},
+		{"gtest", "Values/Param.Run/0", []string{"--gtest_filter=Values/Param.Run/0"}},
+		{"catch2", `a, [b] \ c`, []string{`a\, \[b\] \\ c`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.framework, func(t *testing.T) {
+			if got := Frameworks[test.framework].Run(test.name); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
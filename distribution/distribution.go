@@ -0,0 +1,27 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+// Package distribution embeds the Snake CMake modules and schema. The archives
+// are created with 'make generate-schema'.
+package distribution
+
+import (
+	"embed"
+	"strings"
+)
+
+//go:embed *.zip
+var Archives embed.FS
+
+// Returns the version of the embedded archive (ex. 2.0.0).
+func Version() string {
+	entries, err := Archives.ReadDir(".")
+
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+
+	return strings.TrimSuffix(entries[len(entries)-1].Name(), ".zip")
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Colors used to highlight external packages by provider.
+var providerColors = map[string]string{
+	"conan":  "#a6cee3",
+	"system": "#d9d9d9",
+	"git":    "#fdbf6f",
+	"url":    "#ffff99",
+	"pkg":    "#b2df8a",
+}
+
+// Returns the set of edges that belong to a cycle.
+func (g *Graph) cyclicEdges() map[*Edge]bool {
+	cyclic := map[*Edge]bool{}
+
+	for _, cycle := range g.Cycles() {
+		members := map[string]bool{}
+
+		for _, name := range cycle {
+			members[name] = true
+		}
+
+		for _, name := range cycle {
+			for _, e := range g.outgoing[name] {
+				if members[e.To] {
+					cyclic[e] = true
+				}
+			}
+		}
+	}
+
+	return cyclic
+}
+
+// Write the graph in Graphviz DOT format.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("digraph snake {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+
+	for _, n := range g.Nodes {
+		switch n.Kind {
+		case KindTarget:
+			fmt.Fprintf(&b, "  %q [shape=box, tooltip=%q];\n", n.Name, n.Type)
+		case KindPackage:
+			color, ok := providerColors[n.Provider]
+
+			if !ok {
+				color = "#ffffff"
+			}
+
+			fmt.Fprintf(&b, "  %q [shape=ellipse, style=filled, fillcolor=%q, tooltip=%q];\n",
+				n.Name, color, n.Provider+": "+n.Package)
+		default:
+			fmt.Fprintf(&b, "  %q [shape=ellipse, style=dashed];\n", n.Name)
+		}
+	}
+
+	cyclic := g.cyclicEdges()
+
+	for _, e := range g.Edges {
+		attributes := []string{}
+
+		if e.Link == "PRIVATE" {
+			attributes = append(attributes, "style=dashed")
+		}
+
+		if cyclic[e] {
+			attributes = append(attributes, "color=red")
+		}
+
+		if len(attributes) > 0 {
+			fmt.Fprintf(&b, "  %q -> %q [%s];\n", e.From, e.To, strings.Join(attributes, ", "))
+		} else {
+			fmt.Fprintf(&b, "  %q -> %q;\n", e.From, e.To)
+		}
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// Write the graph as a Mermaid flowchart.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+
+	// Mermaid identifiers cannot contain characters like ':' so we use indices.
+	ids := map[string]string{}
+
+	b.WriteString("flowchart LR\n")
+
+	for i, n := range g.Nodes {
+		ids[n.Name] = fmt.Sprintf("n%d", i)
+
+		label := strings.ReplaceAll(n.Name, "\"", "#quot;")
+
+		if n.Kind == KindTarget {
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[n.Name], label)
+		} else {
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", ids[n.Name], label)
+		}
+	}
+
+	cyclic := g.cyclicEdges()
+	cyclicLinks := []string{}
+
+	for i, e := range g.Edges {
+		if e.Link == "PRIVATE" {
+			fmt.Fprintf(&b, "  %s -.-> %s\n", ids[e.From], ids[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+		}
+
+		if cyclic[e] {
+			cyclicLinks = append(cyclicLinks, fmt.Sprint(i))
+		}
+	}
+
+	if len(cyclicLinks) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:red\n", strings.Join(cyclicLinks, ","))
+	}
+
+	for _, provider := range []string{"conan", "system", "git", "url", "pkg"} {
+		members := []string{}
+
+		for _, n := range g.Nodes {
+			if n.Kind == KindPackage && n.Provider == provider {
+				members = append(members, ids[n.Name])
+			}
+		}
+
+		if len(members) > 0 {
+			fmt.Fprintf(&b, "  classDef %s fill:%s\n", provider, providerColors[provider])
+			fmt.Fprintf(&b, "  class %s %s\n", strings.Join(members, ","), provider)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// Write the graph as JSON including the detected cycles.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	document := struct {
+		Nodes  []*Node    `json:"nodes"`
+		Edges  []*Edge    `json:"edges"`
+		Cycles [][]string `json:"cycles"`
+	}{
+		Nodes:  append([]*Node{}, g.Nodes...),
+		Edges:  append([]*Edge{}, g.Edges...),
+		Cycles: g.Cycles(),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(document)
+}
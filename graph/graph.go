@@ -0,0 +1,339 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sumartian-studios/snake/configuration"
+)
+
+// Kinds of graph nodes.
+const (
+	// A target declared in the configuration.
+	KindTarget = "target"
+
+	// An import provided by a dependency (ex. termcolor::termcolor).
+	KindPackage = "package"
+
+	// A library that is neither a target nor a declared import (ex. pthread).
+	KindLibrary = "library"
+)
+
+// Node represents a target or an external library.
+type Node struct {
+	// The target or import name.
+	Name string `json:"name"`
+
+	// The kind of node (target, package, or library).
+	Kind string `json:"kind"`
+
+	// The target type. Only set for targets.
+	Type string `json:"type,omitempty"`
+
+	// The target path. Only set for targets.
+	Path string `json:"path,omitempty"`
+
+	// The target requirement. Only set for targets.
+	Requirement string `json:"requirement,omitempty"`
+
+	// The dependency provider (ex. conan or system). Only set for packages.
+	Provider string `json:"provider,omitempty"`
+
+	// The dependency package string. Only set for packages.
+	Package string `json:"package,omitempty"`
+}
+
+// Edge represents a link between a target and a library.
+type Edge struct {
+	// The name of the target linking the library.
+	From string `json:"from"`
+
+	// The name of the linked library.
+	To string `json:"to"`
+
+	// The link type (PUBLIC, PRIVATE, or INTERFACE).
+	Link string `json:"link"`
+
+	// The conditions that must evaluate to true for the link to exist. The first
+	// condition is the target requirement followed by the feature condition.
+	Conditions []string `json:"conditions"`
+}
+
+// Graph is the link graph of a configuration.
+type Graph struct {
+	// Nodes in declaration order.
+	Nodes []*Node
+
+	// Edges in declaration order.
+	Edges []*Edge
+
+	nodes    map[string]*Node
+	outgoing map[string][]*Edge
+	incoming map[string][]*Edge
+}
+
+// Create an empty graph.
+func newGraph() *Graph {
+	return &Graph{
+		nodes:    map[string]*Node{},
+		outgoing: map[string][]*Edge{},
+		incoming: map[string][]*Edge{},
+	}
+}
+
+// Build the link graph from the targets and dependencies of a configuration.
+func New(cfg *configuration.Configuration) *Graph {
+	g := newGraph()
+
+	imports := map[string]*Node{}
+
+	if cfg.Dependencies != nil {
+		for _, d := range *cfg.Dependencies {
+			for _, i := range d.Imports {
+				imports[i.Name] = &Node{Name: i.Name, Kind: KindPackage, Provider: d.From, Package: d.Package}
+			}
+		}
+	}
+
+	if cfg.Targets == nil {
+		return g
+	}
+
+	targets := *cfg.Targets
+
+	for _, t := range targets {
+		g.addNode(&Node{Name: t.Name, Kind: KindTarget, Type: t.Type, Path: t.Path, Requirement: t.Requirement})
+	}
+
+	for _, t := range targets {
+		if t.Features == nil {
+			continue
+		}
+
+		for _, feat := range *t.Features {
+			if feat.Libraries == nil {
+				continue
+			}
+
+			conditions := []string{t.Requirement}
+
+			if feat.Condition != nil {
+				conditions = append(conditions, *feat.Condition)
+			}
+
+			for _, lib := range *feat.Libraries {
+				link := "PUBLIC"
+
+				// Same rules as the CMake generator.
+				if t.Type == "header-library" {
+					link = "INTERFACE"
+				} else if strings.ToLower(lib.Type) == "private" {
+					link = "PRIVATE"
+				}
+
+				for _, name := range lib.Targets {
+					if g.nodes[name] == nil {
+						if n := imports[name]; n != nil {
+							g.addNode(n)
+						} else {
+							g.addNode(&Node{Name: name, Kind: KindLibrary})
+						}
+					}
+
+					g.addEdge(&Edge{From: t.Name, To: name, Link: link, Conditions: conditions})
+				}
+			}
+		}
+	}
+
+	return g
+}
+
+func (g *Graph) addNode(n *Node) {
+	if g.nodes[n.Name] != nil {
+		return
+	}
+
+	g.nodes[n.Name] = n
+	g.Nodes = append(g.Nodes, n)
+}
+
+func (g *Graph) addEdge(e *Edge) {
+	g.Edges = append(g.Edges, e)
+	g.outgoing[e.From] = append(g.outgoing[e.From], e)
+	g.incoming[e.To] = append(g.incoming[e.To], e)
+}
+
+// Returns the node with the given name or nil.
+func (g *Graph) Node(name string) *Node {
+	return g.nodes[name]
+}
+
+// Returns the edges leaving a node.
+func (g *Graph) Outgoing(name string) []*Edge {
+	return g.outgoing[name]
+}
+
+// Returns the edges entering a node.
+func (g *Graph) Incoming(name string) []*Edge {
+	return g.incoming[name]
+}
+
+// Returns the names of the nodes reachable from the given nodes (inclusive). If
+// reverse is true the edges are followed backwards (i.e. the dependents).
+func (g *Graph) Reachable(names []string, reverse bool) map[string]bool {
+	visited := map[string]bool{}
+	stack := append([]string{}, names...)
+
+	for len(stack) > 0 {
+		name := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if visited[name] || g.nodes[name] == nil {
+			continue
+		}
+
+		visited[name] = true
+
+		if reverse {
+			for _, e := range g.incoming[name] {
+				stack = append(stack, e.From)
+			}
+		} else {
+			for _, e := range g.outgoing[name] {
+				stack = append(stack, e.To)
+			}
+		}
+	}
+
+	return visited
+}
+
+// Returns the subgraph containing only the given nodes and the edges between them.
+func (g *Graph) Subgraph(keep map[string]bool) *Graph {
+	s := newGraph()
+
+	for _, n := range g.Nodes {
+		if keep[n.Name] {
+			s.addNode(n)
+		}
+	}
+
+	for _, e := range g.Edges {
+		if keep[e.From] && keep[e.To] {
+			s.addEdge(e)
+		}
+	}
+
+	return s
+}
+
+// Returns every cycle in the graph as a list of strongly connected components.
+// Each cycle is sorted by name and self-links are reported as single-node cycles.
+func (g *Graph) Cycles() [][]string {
+	index := 0
+	indices := map[string]int{}
+	lowlinks := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	cycles := [][]string{}
+
+	var connect func(name string)
+
+	// Tarjan's strongly connected components algorithm.
+	connect = func(name string) {
+		indices[name] = index
+		lowlinks[name] = index
+		index++
+
+		stack = append(stack, name)
+		onStack[name] = true
+
+		selfLink := false
+
+		for _, e := range g.outgoing[name] {
+			if e.To == name {
+				selfLink = true
+			}
+
+			if _, ok := indices[e.To]; !ok {
+				connect(e.To)
+
+				if lowlinks[e.To] < lowlinks[name] {
+					lowlinks[name] = lowlinks[e.To]
+				}
+			} else if onStack[e.To] && indices[e.To] < lowlinks[name] {
+				lowlinks[name] = indices[e.To]
+			}
+		}
+
+		if lowlinks[name] != indices[name] {
+			return
+		}
+
+		component := []string{}
+
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+
+			if top == name {
+				break
+			}
+		}
+
+		if len(component) > 1 || selfLink {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if _, ok := indices[n.Name]; !ok {
+			connect(n.Name)
+		}
+	}
+
+	return cycles
+}
+
+// Returns every path leading to the given node. Each path is a list of edges starting
+// at a node without dependents and ending at the given node.
+func (g *Graph) Paths(name string) [][]*Edge {
+	paths := [][]*Edge{}
+	onPath := map[string]bool{}
+
+	var walk func(name string, suffix []*Edge)
+
+	walk = func(name string, suffix []*Edge) {
+		onPath[name] = true
+		defer delete(onPath, name)
+
+		extended := false
+
+		for _, e := range g.incoming[name] {
+			// Cyclic links would produce infinite paths.
+			if onPath[e.From] {
+				continue
+			}
+
+			extended = true
+			walk(e.From, append([]*Edge{e}, suffix...))
+		}
+
+		if !extended && len(suffix) > 0 {
+			paths = append(paths, suffix)
+		}
+	}
+
+	walk(name, nil)
+
+	return paths
+}
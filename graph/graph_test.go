@@ -0,0 +1,225 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sumartian-studios/snake/configuration"
+	"gopkg.in/yaml.v3"
+)
+
+// Returns a graph with a node per name and an edge per "from->to" link.
+func linkGraph(links ...string) *Graph {
+	g := newGraph()
+
+	for _, link := range links {
+		from, to, _ := strings.Cut(link, "->")
+
+		g.addNode(&Node{Name: from, Kind: KindTarget})
+		g.addNode(&Node{Name: to, Kind: KindTarget})
+		g.addEdge(&Edge{From: from, To: to, Link: "PUBLIC"})
+	}
+
+	return g
+}
+
+// Returns the edges of a path as "from->to" links.
+func pathLinks(path []*Edge) []string {
+	links := []string{}
+
+	for _, e := range path {
+		links = append(links, e.From+"->"+e.To)
+	}
+
+	return links
+}
+
+func TestNew(t *testing.T) {
+	var cfg configuration.Configuration
+
+	err := yaml.Unmarshal([]byte(`
+Dependencies:
+  - from: conan
+    package: fmt/10.0.0
+    imports:
+      - target: fmt::fmt
+        find: fmt REQUIRED
+Targets:
+  - name: app
+    type: executable
+    requirement: SNAKE_ALWAYS_BUILD
+    features:
+      - libraries:
+          - type: private
+            targets: [core, pthread]
+      - if: WIN32
+        libraries:
+          - type: public
+            targets: [fmt::fmt]
+  - name: core
+    type: header-library
+    features:
+      - libraries:
+          - type: private
+            targets: [fmt::fmt]
+`), &cfg)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := New(&cfg)
+
+	nodes := []Node{}
+
+	for _, n := range g.Nodes {
+		nodes = append(nodes, *n)
+	}
+
+	wantNodes := []Node{
+		{Name: "app", Kind: KindTarget, Type: "executable", Requirement: "SNAKE_ALWAYS_BUILD"},
+		{Name: "core", Kind: KindTarget, Type: "header-library"},
+		{Name: "pthread", Kind: KindLibrary},
+		{Name: "fmt::fmt", Kind: KindPackage, Provider: "conan", Package: "fmt/10.0.0"},
+	}
+
+	if !reflect.DeepEqual(nodes, wantNodes) {
+		t.Errorf("got nodes %+v, want %+v", nodes, wantNodes)
+	}
+
+	edges := []Edge{}
+
+	for _, e := range g.Edges {
+		edges = append(edges, *e)
+	}
+
+	wantEdges := []Edge{
+		{From: "app", To: "core", Link: "PRIVATE", Conditions: []string{"SNAKE_ALWAYS_BUILD"}},
+		{From: "app", To: "pthread", Link: "PRIVATE", Conditions: []string{"SNAKE_ALWAYS_BUILD"}},
+		{From: "app", To: "fmt::fmt", Link: "PUBLIC", Conditions: []string{"SNAKE_ALWAYS_BUILD", "WIN32"}},
+		{From: "core", To: "fmt::fmt", Link: "INTERFACE", Conditions: []string{""}},
+	}
+
+	if !reflect.DeepEqual(edges, wantEdges) {
+		t.Errorf("got edges %+v, want %+v", edges, wantEdges)
+	}
+}
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		links []string
+		want  [][]string
+	}{
+		{
+			name:  "acyclic",
+			links: []string{"a->b", "b->c", "a->c"},
+			want:  [][]string{},
+		},
+		{
+			name:  "two nodes",
+			links: []string{"a->b", "b->a"},
+			want:  [][]string{{"a", "b"}},
+		},
+		{
+			name:  "self-link",
+			links: []string{"a->a", "a->b"},
+			want:  [][]string{{"a"}},
+		},
+		{
+			name:  "nested cycles form one component",
+			links: []string{"c->a", "a->b", "b->c", "b->a", "c->d"},
+			want:  [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:  "separate cycles",
+			links: []string{"a->b", "b->a", "b->c", "c->d", "d->e", "e->c"},
+			want:  [][]string{{"c", "d", "e"}, {"a", "b"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := linkGraph(test.links...).Cycles(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		links  []string
+		target string
+		want   [][]string
+	}{
+		{
+			name:   "no dependents",
+			links:  []string{"a->b"},
+			target: "a",
+			want:   [][]string{},
+		},
+		{
+			name:   "diamond",
+			links:  []string{"app->ui", "app->net", "ui->core", "net->core"},
+			target: "core",
+			want:   [][]string{{"app->ui", "ui->core"}, {"app->net", "net->core"}},
+		},
+		{
+			name:   "several roots",
+			links:  []string{"app->core", "test->core"},
+			target: "core",
+			want:   [][]string{{"app->core"}, {"test->core"}},
+		},
+		{
+			name:   "cycles are cut",
+			links:  []string{"app->a", "a->b", "b->a"},
+			target: "b",
+			want:   [][]string{{"app->a", "a->b"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := [][]string{}
+
+			for _, path := range linkGraph(test.links...).Paths(test.target) {
+				got = append(got, pathLinks(path))
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReachable(t *testing.T) {
+	g := linkGraph("app->ui", "ui->core", "test->core", "core->fmt")
+
+	tests := []struct {
+		name    string
+		from    []string
+		reverse bool
+		want    map[string]bool
+	}{
+		{"dependencies", []string{"ui"}, false, map[string]bool{"ui": true, "core": true, "fmt": true}},
+		{"dependents", []string{"core"}, true, map[string]bool{"core": true, "ui": true, "app": true, "test": true}},
+		{"unknown nodes", []string{"missing"}, false, map[string]bool{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := g.Reachable(test.from, test.reverse); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
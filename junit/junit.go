@@ -0,0 +1,89 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package junit
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Property is a name and value pair attached to a suite or a test case.
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Message is a failure or a skip reason.
+type Message struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// TestCase is the result of a test.
+type TestCase struct {
+	Name       string     `xml:"name,attr"`
+	ClassName  string     `xml:"classname,attr"`
+	Time       float64    `xml:"time,attr"`
+	Status     string     `xml:"status,attr,omitempty"`
+	Properties []Property `xml:"properties>property,omitempty"`
+	Failure    *Message   `xml:"failure,omitempty"`
+	Skipped    *Message   `xml:"skipped,omitempty"`
+	SystemOut  string     `xml:"system-out,omitempty"`
+}
+
+// TestSuite is a group of test cases.
+type TestSuite struct {
+	XMLName    xml.Name   `xml:"testsuite"`
+	Name       string     `xml:"name,attr"`
+	Tests      int        `xml:"tests,attr"`
+	Failures   int        `xml:"failures,attr"`
+	Skipped    int        `xml:"skipped,attr"`
+	Time       float64    `xml:"time,attr"`
+	Timestamp  string     `xml:"timestamp,attr,omitempty"`
+	Hostname   string     `xml:"hostname,attr,omitempty"`
+	Properties []Property `xml:"properties>property,omitempty"`
+	TestCases  []TestCase `xml:"testcase"`
+}
+
+// TestSuites is the root element of a JUnit report.
+type TestSuites struct {
+	XMLName    xml.Name    `xml:"testsuites"`
+	Name       string      `xml:"name,attr"`
+	Tests      int         `xml:"tests,attr"`
+	Failures   int         `xml:"failures,attr"`
+	Skipped    int         `xml:"skipped,attr"`
+	Time       float64     `xml:"time,attr"`
+	TestSuites []TestSuite `xml:"testsuite"`
+}
+
+// Read a JUnit report with a single test suite such as the ones written by
+// 'ctest --output-junit'.
+func ReadSuite(r io.Reader) (*TestSuite, error) {
+	suite := new(TestSuite)
+
+	if err := xml.NewDecoder(r).Decode(suite); err != nil {
+		return nil, err
+	}
+
+	return suite, nil
+}
+
+// Write a JUnit report.
+func Write(w io.Writer, suites *TestSuites) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -6,19 +6,15 @@
 package main
 
 import (
-	"embed"
 	"fmt"
 	"os"
 
 	"github.com/sumartian-studios/snake/application"
 )
 
-//go:embed distribution/2.0.0.zip
-var dataZip embed.FS
-
 func main() {
-	if err := application.Execute(&dataZip); err != nil {
+	if err := application.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		os.Exit(application.ExitCode(err))
 	}
 }
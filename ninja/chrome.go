@@ -0,0 +1,66 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package ninja
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sumartian-studios/snake/trace"
+)
+
+// WriteChrome converts the steps to the Chrome trace-event format. Each step is
+// placed on the first free thread so the threads show how the jobs were used.
+func WriteChrome(w io.Writer, edges []Edge, target func(output string) string) error {
+	sorted := append([]Edge{}, edges...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	out := trace.ChromeTrace{TraceEvents: []trace.ChromeEvent{}, DisplayTimeUnit: "ms"}
+
+	// End time of the last step of each thread.
+	threads := []int{}
+
+	for _, e := range sorted {
+		tid := -1
+
+		for i, end := range threads {
+			if end <= e.Start {
+				tid = i
+				break
+			}
+		}
+
+		if tid == -1 {
+			tid = len(threads)
+			threads = append(threads, 0)
+		}
+
+		threads[tid] = e.End
+
+		out.TraceEvents = append(out.TraceEvents, trace.ChromeEvent{
+			Name: e.Outputs[0],
+			Cat:  target(e.Outputs[0]),
+			Ph:   "X",
+			Ts:   float64(e.Start) * 1000,
+			Dur:  float64(e.Duration()) * 1000,
+			Pid:  1,
+			Tid:  tid,
+			Args: map[string]interface{}{
+				"outputs": strings.Join(e.Outputs, " "),
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+
+	return encoder.Encode(out)
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package ninja
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Edge is a build step recorded in the Ninja log.
+type Edge struct {
+	// Outputs produced by the step.
+	Outputs []string `json:"outputs"`
+
+	// Time at which the step started (in milliseconds since the start of the build).
+	Start int `json:"start"`
+
+	// Time at which the step ended (in milliseconds since the start of the build).
+	End int `json:"end"`
+
+	// Hash of the command.
+	Hash string `json:"-"`
+}
+
+// Returns the duration of the step (in milliseconds).
+func (e *Edge) Duration() int {
+	return e.End - e.Start
+}
+
+// Read the steps of the last build in a .ninja_log file. Ninja appends the steps
+// in completion order so a new build starts when the end time goes backwards.
+func ReadLog(r io.Reader) ([]Edge, error) {
+	scanner := bufio.NewScanner(r)
+
+	if !scanner.Scan() {
+		return nil, scanner.Err()
+	}
+
+	if header := scanner.Text(); !strings.HasPrefix(header, "# ninja log v") {
+		return nil, fmt.Errorf("unsupported ninja log: %s", header)
+	}
+
+	edges := []Edge{}
+	index := map[string]int{}
+	lastEnd := -1
+
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+
+		if len(fields) < 5 {
+			continue
+		}
+
+		start, err := strconv.Atoi(fields[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := strconv.Atoi(fields[1])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if end < lastEnd {
+			edges = []Edge{}
+			index = map[string]int{}
+		}
+
+		lastEnd = end
+
+		// Steps with several outputs are written once per output.
+		key := fields[0] + "\t" + fields[1] + "\t" + fields[4]
+
+		if i, ok := index[key]; ok {
+			edges[i].Outputs = append(edges[i].Outputs, fields[3])
+			continue
+		}
+
+		index[key] = len(edges)
+		edges = append(edges, Edge{Outputs: []string{fields[3]}, Start: start, End: end, Hash: fields[4]})
+	}
+
+	return edges, scanner.Err()
+}
+
+// Returns the wall time of the build (in milliseconds).
+func Duration(edges []Edge) int {
+	if len(edges) == 0 {
+		return 0
+	}
+
+	start, end := edges[0].Start, edges[0].End
+
+	for _, e := range edges {
+		if e.Start < start {
+			start = e.Start
+		}
+
+		if e.End > end {
+			end = e.End
+		}
+	}
+
+	return end - start
+}
+
+// Returns the sum of the step durations (in milliseconds).
+func Total(edges []Edge) int {
+	total := 0
+
+	for _, e := range edges {
+		total += e.Duration()
+	}
+
+	return total
+}
+
+// Estimate the critical path of the build. The log does not contain the
+// dependencies so the step that finished last before a step started is assumed
+// to be the one it was waiting for. The path is returned in build order.
+func CriticalPath(edges []Edge) []Edge {
+	if len(edges) == 0 {
+		return nil
+	}
+
+	sorted := append([]Edge{}, edges...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].End < sorted[j].End
+	})
+
+	k := len(sorted) - 1
+	path := []Edge{sorted[k]}
+
+	for {
+		start := sorted[k].Start
+
+		// Only look at the steps before the current one so empty steps cannot loop.
+		i := sort.Search(k, func(i int) bool {
+			return sorted[i].End > start
+		})
+
+		if i == 0 {
+			break
+		}
+
+		k = i - 1
+		path = append(path, sorted[k])
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
+
+// Sample is the average number of steps running during an interval.
+type Sample struct {
+	// Start of the interval (in milliseconds since the start of the build).
+	Start int `json:"start"`
+
+	// End of the interval (in milliseconds since the start of the build).
+	End int `json:"end"`
+
+	// Average number of concurrent steps.
+	Parallelism float64 `json:"parallelism"`
+}
+
+// Returns the parallelism of the build split into a number of intervals.
+func Parallelism(edges []Edge, intervals int) []Sample {
+	duration := Duration(edges)
+
+	if duration <= 0 || intervals < 1 {
+		return []Sample{}
+	}
+
+	start := edges[0].Start
+
+	for _, e := range edges {
+		if e.Start < start {
+			start = e.Start
+		}
+	}
+
+	samples := []Sample{}
+
+	for i := 0; i < intervals; i++ {
+		sample := Sample{
+			Start: start + i*duration/intervals,
+			End:   start + (i+1)*duration/intervals,
+		}
+
+		if sample.End <= sample.Start {
+			continue
+		}
+
+		busy := 0
+
+		for _, e := range edges {
+			from, to := e.Start, e.End
+
+			if from < sample.Start {
+				from = sample.Start
+			}
+
+			if to > sample.End {
+				to = sample.End
+			}
+
+			if to > from {
+				busy += to - from
+			}
+		}
+
+		sample.Parallelism = float64(busy) / float64(sample.End-sample.Start)
+		samples = append(samples, sample)
+	}
+
+	return samples
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package ninja
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadLog(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []Edge
+		err  bool
+	}{
+		{
+			name: "single build",
+			log:  "# ninja log v5\n10\t50\t0\tb.o\th2\n0\t100\t0\ta.o\th1\n",
+			want: []Edge{
+				{Outputs: []string{"b.o"}, Start: 10, End: 50, Hash: "h2"},
+				{Outputs: []string{"a.o"}, Start: 0, End: 100, Hash: "h1"},
+			},
+		},
+		{
+			name: "several outputs",
+			log:  "# ninja log v6\n0\t80\t0\tmoc.cpp\th1\n0\t80\t0\tmoc.h\th1\n",
+			want: []Edge{{Outputs: []string{"moc.cpp", "moc.h"}, Start: 0, End: 80, Hash: "h1"}},
+		},
+		{
+			name: "only the last build",
+			log:  "# ninja log v5\n0\t100\t0\ta.o\th1\n100\t900\t0\tapp\th2\n0\t20\t0\ta.o\th3\n20\t70\t0\tapp\th4\n",
+			want: []Edge{
+				{Outputs: []string{"a.o"}, Start: 0, End: 20, Hash: "h3"},
+				{Outputs: []string{"app"}, Start: 20, End: 70, Hash: "h4"},
+			},
+		},
+		{
+			name: "incomplete lines",
+			log:  "# ninja log v5\n0\t100\n",
+			want: []Edge{},
+		},
+		{
+			name: "empty",
+			log:  "",
+			want: nil,
+		},
+		{
+			name: "unsupported",
+			log:  "ninja_deps\n",
+			err:  true,
+		},
+		{
+			name: "invalid time",
+			log:  "# ninja log v5\nx\t100\t0\ta.o\th1\n",
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadLog(strings.NewReader(test.log))
+
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// Returns the steps of a build.
+func edges(steps ...Edge) []Edge {
+	return steps
+}
+
+// Returns a step with a single output.
+func step(output string, start int, end int) Edge {
+	return Edge{Outputs: []string{output}, Start: start, End: end}
+}
+
+func TestCriticalPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []Edge
+		want  []string
+	}{
+		{
+			name:  "empty",
+			edges: edges(),
+			want:  []string{},
+		},
+		{
+			name:  "chain through the last step",
+			edges: edges(step("a", 0, 100), step("b", 0, 50), step("c", 100, 300), step("d", 50, 120), step("e", 300, 310)),
+			want:  []string{"a", "c", "e"},
+		},
+		{
+			name:  "empty steps do not loop",
+			edges: edges(step("a", 0, 10), step("b", 10, 10), step("c", 10, 10)),
+			want:  []string{"a", "b", "c"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{}
+
+			for _, e := range CriticalPath(test.edges) {
+				got = append(got, e.Outputs[0])
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParallelism(t *testing.T) {
+	build := edges(step("a", 0, 100), step("b", 0, 50), step("c", 50, 100), step("d", 50, 75))
+
+	if got, want := Duration(build), 100; got != want {
+		t.Errorf("got duration %d, want %d", got, want)
+	}
+
+	if got, want := Total(build), 225; got != want {
+		t.Errorf("got total %d, want %d", got, want)
+	}
+
+	tests := []struct {
+		name      string
+		edges     []Edge
+		intervals int
+		want      []Sample
+	}{
+		{"whole build", build, 1, []Sample{{Start: 0, End: 100, Parallelism: 2.25}}},
+		{"intervals", build, 4, []Sample{
+			{Start: 0, End: 25, Parallelism: 2},
+			{Start: 25, End: 50, Parallelism: 2},
+			{Start: 50, End: 75, Parallelism: 3},
+			{Start: 75, End: 100, Parallelism: 2},
+		}},
+		{"more intervals than milliseconds", edges(step("a", 0, 2)), 4, []Sample{
+			{Start: 0, End: 1, Parallelism: 1},
+			{Start: 1, End: 2, Parallelism: 1},
+		}},
+		{"empty build", edges(step("a", 5, 5)), 4, []Sample{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Parallelism(test.edges, test.intervals); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,10 @@ import (
 type Suggestion struct {
 	Prefix   string
 	children []string
+
+	// Returns additional children when completing (ex. values changing while the
+	// REPL is running).
+	Dynamic func() []string
 }
 
 // Add a new suggestion string.
@@ -73,7 +77,13 @@ func (suggester *Suggester) Do(line []rune, pos int) (suggestions [][]rune, offs
 	for _, suggestion := range suggester.Suggestions {
 		if p := strings.ToLower(suggestion.Prefix); strings.HasPrefix(p, prefix) {
 			if argument {
-				for _, child := range suggestion.children {
+				children := suggestion.children
+
+				if suggestion.Dynamic != nil {
+					children = append(append([]string{}, children...), suggestion.Dynamic()...)
+				}
+
+				for _, child := range children {
 					if s := strings.ToLower(child); strings.HasPrefix(s, suffix) {
 						if child[len(child)-1] == '=' {
 							suggestions = append(suggestions, []rune(child[len(suffix):]))
@@ -0,0 +1,144 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package runner
+
+import (
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaced by the script arguments.
+const argsPlaceholder = "{{args}}"
+
+// Split a command into arguments. Single and double quotes group arguments and
+// backslashes escape the next character outside of single quotes.
+func SplitArgs(s string) []string {
+	args := []string{}
+
+	var current strings.Builder
+	var quote rune
+
+	inArg, escaped := false, false
+
+	for _, c := range s {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
+
+// Quote arguments so a POSIX shell reads them back unchanged.
+func QuoteArgs(args []string) string {
+	quoted := []string{}
+
+	for _, arg := range args {
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// Replace the placeholder in a command run by a shell.
+func expandShellArgs(command string, args []string) string {
+	return strings.ReplaceAll(command, argsPlaceholder, QuoteArgs(args))
+}
+
+// Replace the placeholder in a command executed without a shell. A standalone
+// placeholder becomes one argument per script argument.
+func expandArgs(argv []string, args []string) []string {
+	expanded := []string{}
+
+	for _, arg := range argv {
+		if arg == argsPlaceholder {
+			expanded = append(expanded, args...)
+		} else {
+			expanded = append(expanded, strings.ReplaceAll(arg, argsPlaceholder, strings.Join(args, " ")))
+		}
+	}
+
+	return expanded
+}
+
+// Returns the files matching a pattern relative to a directory. Like CMake's
+// file(GLOB_RECURSE) the wildcards also match files in subdirectories.
+func Glob(dir string, pattern string) []string {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	pattern = filepath.ToSlash(pattern)
+
+	// Walk from the deepest directory without wildcards.
+	base := pattern
+
+	for strings.ContainsAny(base, "*?") {
+		base = filepath.ToSlash(filepath.Dir(base))
+	}
+
+	var expression strings.Builder
+
+	expression.WriteString("^")
+
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			expression.WriteString(".*")
+		case '?':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	expression.WriteString("$")
+
+	matcher, err := regexp.Compile(expression.String())
+
+	if err != nil {
+		return nil
+	}
+
+	files := []string{}
+
+	filepath.WalkDir(filepath.FromSlash(base), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && matcher.MatchString(filepath.ToSlash(path)) {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files
+}
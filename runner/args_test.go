@@ -0,0 +1,137 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"", []string{}},
+		{"  cmake  --build\tbuild\n", []string{"cmake", "--build", "build"}},
+		{`echo "hello world" 'a b'`, []string{"echo", "hello world", "a b"}},
+		{`echo a"b c"d`, []string{"echo", "ab cd"}},
+		{`echo "" ''`, []string{"echo", "", ""}},
+		{`echo a\ b \"c\"`, []string{"echo", "a b", `"c"`}},
+		{`echo "say \"hi\""`, []string{"echo", `say "hi"`}},
+		{`echo 'C:\path'`, []string{"echo", `C:\path`}},
+		{`echo "it's"`, []string{"echo", "it's"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			if got := SplitArgs(test.command); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQuoteArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, ""},
+		{[]string{"a", "b c"}, `'a' 'b c'`},
+		{[]string{"it's", "$HOME"}, `'it'\''s' '$HOME'`},
+		{[]string{""}, `''`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			got := QuoteArgs(test.args)
+
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+
+			// SplitArgs reads the quoted arguments back.
+			if back := SplitArgs(got); !reflect.DeepEqual(back, test.args) {
+				t.Errorf("split back to %q, want %q", back, test.args)
+			}
+		})
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	args := []string{"-v", "a b"}
+
+	tests := []struct {
+		name string
+		argv []string
+		want []string
+	}{
+		{"standalone", []string{"tool", "{{args}}", "out"}, []string{"tool", "-v", "a b", "out"}},
+		{"embedded", []string{"tool", "--flags={{args}}"}, []string{"tool", "--flags=-v a b"}},
+		{"absent", []string{"tool"}, []string{"tool"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := expandArgs(test.argv, args); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if got, want := expandShellArgs("tool {{args}} | cat", args), `tool '-v' 'a b' | cat`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"a.txt", "b.cc", "src/c.cc", "src/sub/d.cc", "src/e.h"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"a.txt", []string{"a.txt"}},
+		{"*.cc", []string{"b.cc", "src/c.cc", "src/sub/d.cc"}},
+		{"src/*.cc", []string{"src/c.cc", "src/sub/d.cc"}},
+		{"src/?.h", []string{"src/e.h"}},
+		{filepath.ToSlash(filepath.Join(dir, "src", "sub", "*")), []string{"src/sub/d.cc"}},
+		{"missing/*", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			got := []string{}
+
+			for _, path := range Glob(dir, test.pattern) {
+				rel, _ := filepath.Rel(dir, path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,492 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sumartian-studios/snake/configuration"
+	"github.com/sumartian-studios/snake/utilities"
+)
+
+// Matches CMake style variable references (ex. ${CMAKE_SOURCE_DIR}).
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// Runner executes scripts natively without going through the build system.
+type Runner struct {
+	// Scripts by name.
+	Scripts map[string]*configuration.Script
+
+	// The directory used to run commands and resolve relative paths.
+	Dir string
+
+	// Variables substituted in commands (ex. CMAKE_SOURCE_DIR). Unknown variables are
+	// left for the shell to expand.
+	Variables map[string]string
+
+	// Environment of the commands.
+	Env []string
+
+	// Maximum number of scripts running concurrently.
+	Jobs int
+
+	// Run scripts even if their products are up to date.
+	Force bool
+
+	// The profile used to select script overrides.
+	Profile string
+
+	// Arguments replacing the "{{args}}" placeholder of the requested scripts.
+	Args []string
+
+	// Names of the build targets scripts may require.
+	Targets map[string]bool
+
+	// Called with the required build targets before running any script.
+	Build func(targets []string) error
+
+	// Context used to run commands. Cancelling it interrupts the running commands.
+	Context context.Context
+
+	// Output streams. Each line is prefixed with the script name.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	mu sync.Mutex
+}
+
+// Create a runner for the given scripts.
+func New(scripts []configuration.Script, dir string) *Runner {
+	r := &Runner{
+		Scripts:   map[string]*configuration.Script{},
+		Dir:       dir,
+		Variables: map[string]string{},
+		Targets:   map[string]bool{},
+		Env:       os.Environ(),
+		Context:   context.Background(),
+		Jobs:      runtime.NumCPU(),
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}
+
+	for i := range scripts {
+		r.Scripts[scripts[i].Name] = &scripts[i]
+	}
+
+	return r
+}
+
+// Returns the scripts required by a script.
+func (r *Runner) dependencies(s *configuration.Script) []string {
+	deps := []string{}
+
+	if s.Requires != nil {
+		for _, name := range *s.Requires {
+			if r.Scripts[name] != nil {
+				deps = append(deps, name)
+			}
+		}
+	}
+
+	return deps
+}
+
+// Returns the files required by a script including its inputs.
+func (r *Runner) inputs(s *configuration.Script) []string {
+	files := []string{}
+
+	if s.Requires != nil {
+		for _, name := range *s.Requires {
+			if r.Scripts[name] == nil && !r.Targets[name] {
+				files = append(files, r.path(name))
+			}
+		}
+	}
+
+	for _, pattern := range s.Inputs {
+		files = append(files, Glob(r.Dir, r.Expand(pattern))...)
+	}
+
+	return files
+}
+
+// Returns the files produced by a script.
+func (r *Runner) products(s *configuration.Script) []string {
+	files := []string{}
+
+	if s.Products != nil {
+		for _, name := range *s.Products {
+			files = append(files, r.path(name))
+		}
+	}
+
+	return files
+}
+
+// Expand variables and resolve a path relative to the runner directory.
+func (r *Runner) path(name string) string {
+	name = r.Expand(name)
+
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	return filepath.Join(r.Dir, name)
+}
+
+// Substitute the known variables in a string.
+func (r *Runner) Expand(s string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := r.Variables[m[2:len(m)-1]]; ok {
+			return v
+		}
+
+		return m
+	})
+}
+
+// Returns the scripts to run (dependencies first) or an error if there is a cycle.
+func (r *Runner) Plan(names []string) ([]string, error) {
+	order := []string{}
+	state := map[string]int{}
+
+	const visiting, visited = 1, 2
+
+	var visit func(name string, path []string) error
+
+	visit = func(name string, path []string) error {
+		s := r.Scripts[name]
+
+		if s == nil {
+			return fmt.Errorf("unknown script: %s", name)
+		}
+
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("script dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+
+		for _, dep := range r.dependencies(s) {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+		order = append(order, name)
+
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// Returns true if every product exists and is newer than every input.
+func (r *Runner) upToDate(s *configuration.Script) bool {
+	products := r.products(s)
+
+	if len(products) == 0 {
+		return false
+	}
+
+	var oldest time.Time
+
+	for i, p := range products {
+		info, err := os.Stat(p)
+
+		if err != nil {
+			return false
+		}
+
+		if i == 0 || info.ModTime().Before(oldest) {
+			oldest = info.ModTime()
+		}
+	}
+
+	for _, input := range r.inputs(s) {
+		info, err := os.Stat(input)
+
+		if err != nil || info.ModTime().After(oldest) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ScriptError is returned when a script command fails.
+type ScriptError struct {
+	// Name of the failed script.
+	Script string
+
+	// The command error.
+	Err error
+}
+
+func (e *ScriptError) Error() string {
+	return e.Script + ": " + e.Err.Error()
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// The result of a script.
+type result struct {
+	// True if the script commands were executed.
+	ran bool
+
+	// The error returned by a command.
+	err error
+}
+
+// Run the scripts and their dependencies. Independent scripts run in parallel.
+func (r *Runner) Run(names []string) error {
+	order, err := r.Plan(names)
+
+	if err != nil {
+		return err
+	}
+
+	targets := []string{}
+
+	for _, name := range order {
+		if s := r.Scripts[name]; s.Requires != nil {
+			for _, dep := range *s.Requires {
+				if r.Targets[dep] && r.Scripts[dep] == nil {
+					targets = append(targets, dep)
+				}
+			}
+		}
+	}
+
+	if len(targets) > 0 && r.Build != nil {
+		if err := r.Build(targets); err != nil {
+			return err
+		}
+	}
+
+	jobs := r.Jobs
+
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	semaphore := make(chan struct{}, jobs)
+	done := map[string]chan struct{}{}
+	results := map[string]*result{}
+
+	for _, name := range order {
+		done[name] = make(chan struct{})
+		results[name] = &result{}
+	}
+
+	// Only the requested scripts receive the arguments.
+	requested := map[string]bool{}
+
+	for _, name := range names {
+		requested[name] = true
+	}
+
+	for _, name := range order {
+		go func(s *configuration.Script) {
+			defer close(done[s.Name])
+
+			res := results[s.Name]
+			stale := r.Force
+
+			for _, dep := range r.dependencies(s) {
+				<-done[dep]
+
+				if results[dep].err != nil {
+					res.err = fmt.Errorf("%s: dependency failed: %s", s.Name, dep)
+					return
+				}
+
+				stale = stale || results[dep].ran
+			}
+
+			if !stale && r.upToDate(s) {
+				r.printf("[%s] up to date\n", s.Name)
+				return
+			}
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			args := []string{}
+
+			if requested[s.Name] {
+				args = r.Args
+			}
+
+			res.ran = true
+			res.err = r.execute(s, args)
+		}(r.Scripts[name])
+	}
+
+	// Report the first failure in execution order.
+	for _, name := range order {
+		<-done[name]
+	}
+
+	for _, name := range order {
+		if results[name].err != nil {
+			return results[name].err
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) printf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintf(r.Stdout, format, args...)
+}
+
+// Run the commands of a script one after another.
+func (r *Runner) execute(s *configuration.Script, args []string) error {
+	stdout := &prefixWriter{prefix: "[" + s.Name + "] ", w: r.Stdout, mu: &r.mu}
+	stderr := &prefixWriter{prefix: "[" + s.Name + "] ", w: r.Stderr, mu: &r.mu}
+
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	options := s.ForProfile(r.Profile)
+
+	dir := r.Dir
+
+	if len(options.Cwd) > 0 {
+		dir = r.path(options.Cwd)
+	}
+
+	env := append([]string{}, r.Env...)
+	keys := []string{}
+
+	for k := range options.Env {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		env = append(env, k+"="+r.Expand(options.Env[k]))
+	}
+
+	for _, command := range options.Commands {
+		if len(command) == 0 {
+			continue
+		}
+
+		cmd, err := r.command(options.Shell, r.Expand(command), args)
+
+		if err != nil {
+			return &ScriptError{Script: s.Name, Err: err}
+		}
+
+		stdout.Write([]byte("$ " + strings.Join(cmd.Args, " ") + "\n"))
+
+		cmd.Dir = dir
+		cmd.Env = env
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		if err := utilities.RunCommand(r.Context, cmd, false); err != nil {
+			return &ScriptError{Script: s.Name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Returns the command for a script command string like the build system would run it.
+func (r *Runner) command(shell string, command string, args []string) (*exec.Cmd, error) {
+	switch shell {
+	case "":
+		command = expandShellArgs(command, args)
+
+		if runtime.GOOS == "windows" {
+			return exec.Command("cmd", "/C", command), nil
+		}
+
+		return exec.Command("/bin/sh", "-c", command), nil
+	case "sh", "bash":
+		return exec.Command(shell, "-c", expandShellArgs(command, args)), nil
+	case "none":
+		argv := expandArgs(SplitArgs(command), args)
+
+		if len(argv) == 0 {
+			return nil, fmt.Errorf("empty command")
+		}
+
+		return exec.Command(argv[0], argv[1:]...), nil
+	}
+
+	return nil, fmt.Errorf("unsupported shell: %s", shell)
+}
+
+// Writes complete lines prefixed with a string.
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+	mu     *sync.Mutex
+	buffer []byte
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buffer = append(p.buffer, data...)
+
+	for {
+		i := bytes.IndexByte(p.buffer, '\n')
+
+		if i < 0 {
+			break
+		}
+
+		p.mu.Lock()
+		_, err := fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buffer[:i])
+		p.mu.Unlock()
+
+		p.buffer = p.buffer[i+1:]
+
+		if err != nil {
+			return len(data), err
+		}
+	}
+
+	return len(data), nil
+}
+
+// Write the remaining partial line.
+func (p *prefixWriter) Flush() {
+	if len(p.buffer) > 0 {
+		p.Write([]byte("\n"))
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sumartian-studios/snake/configuration"
+)
+
+// Returns a script requiring other scripts or files.
+func script(name string, requires ...string) configuration.Script {
+	return configuration.Script{Name: name, Requires: &requires}
+}
+
+func TestPlan(t *testing.T) {
+	r := New([]configuration.Script{
+		script("package", "build", "docs"),
+		script("build", "generate", "CMakeLists.txt"),
+		script("docs", "generate"),
+		script("generate"),
+		script("a", "b"),
+		script("b", "c"),
+		script("c", "a"),
+	}, ".")
+
+	tests := []struct {
+		name    string
+		scripts []string
+		want    []string
+		err     string
+	}{
+		{"single", []string{"generate"}, []string{"generate"}, ""},
+		{"dependencies first", []string{"package"}, []string{"generate", "build", "docs", "package"}, ""},
+		{"shared dependencies run once", []string{"docs", "build"}, []string{"generate", "docs", "build"}, ""},
+		{"cycle", []string{"a"}, nil, "script dependency cycle: a -> b -> c -> a"},
+		{"unknown", []string{"missing"}, nil, "unknown script: missing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := r.Plan(test.scripts)
+
+			if len(test.err) > 0 {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %s", err, test.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	r := New(nil, ".")
+	r.Variables["CMAKE_SOURCE_DIR"] = "/src"
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"${CMAKE_SOURCE_DIR}/gen.txt", "/src/gen.txt"},
+		{"${CMAKE_SOURCE_DIR}:${CMAKE_SOURCE_DIR}", "/src:/src"},
+		{"${HOME}/x", "${HOME}/x"},
+		{"$CMAKE_SOURCE_DIR", "$CMAKE_SOURCE_DIR"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := r.Expand(test.input); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	old, recent := time.Now().Add(-time.Hour), time.Now()
+
+	for name, modified := range map[string]time.Time{"old.txt": old, "new.txt": recent, "in/a.c": old} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		products []string
+		requires []string
+		inputs   []string
+		want     bool
+	}{
+		{"no products", nil, nil, []string{"in/*.c"}, false},
+		{"missing product", []string{"missing.txt"}, nil, nil, false},
+		{"newer than the inputs", []string{"new.txt"}, []string{"old.txt"}, []string{"in/*.c"}, true},
+		{"older than an input", []string{"old.txt"}, nil, []string{"new.txt"}, false},
+		{"missing required file", []string{"new.txt"}, []string{"missing.txt"}, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := configuration.Script{Name: "s", Requires: &test.requires, Inputs: test.inputs}
+
+			if test.products != nil {
+				s.Products = &test.products
+			}
+
+			r := New([]configuration.Script{s}, dir)
+
+			if got := r.upToDate(r.Scripts["s"]); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
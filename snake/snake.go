@@ -0,0 +1,161 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+// Package snake lets Go programs generate, configure, build, and test Snake projects.
+// The snake command-line interface is a thin layer over the same operations.
+package snake
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sumartian-studios/snake/application"
+)
+
+// Describes a target of the project.
+type TargetInfo = application.TargetInfo
+
+// Describes a profile of the project.
+type ProfileInfo = application.ProfileInfo
+
+// Returned when a tool exits with a non-zero code. Use ExitCode to retrieve it.
+type ExitError = application.ExitError
+
+// Returns the exit code the snake executable would use for an error.
+func ExitCode(err error) int {
+	return application.ExitCode(err)
+}
+
+// Options used to open a project.
+type Options struct {
+	// The Snake directory. Defaults to the "build" directory of the project.
+	SnakeDir string
+
+	// Output of Snake and of the tools it runs. Defaults to os.Stdout and os.Stderr.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Enable verbose logging.
+	Verbose bool
+}
+
+// Project is a handle to a Snake project. It is not safe for concurrent use.
+type Project struct {
+	app *application.Application
+}
+
+// Open the project in the root directory and load its configuration.
+func Open(rootDir string, opts Options) (*Project, error) {
+	if len(opts.SnakeDir) == 0 {
+		opts.SnakeDir = filepath.Join(rootDir, "build")
+	}
+
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+
+	app := application.New(rootDir, opts.SnakeDir)
+
+	app.SetOut(opts.Stdout)
+	app.SetErr(opts.Stderr)
+	app.SetVerbose(opts.Verbose)
+
+	if err := app.Load(); err != nil {
+		return nil, err
+	}
+
+	return &Project{app: app}, nil
+}
+
+// Run an operation under a context. Cancelling the context kills the running tools.
+func (p *Project) with(ctx context.Context, f func() error) error {
+	p.app.SetContext(ctx)
+	defer p.app.SetContext(nil)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return f()
+}
+
+// Generate the CMakeLists.txt of the project.
+func (p *Project) Generate(ctx context.Context) error {
+	return p.with(ctx, p.app.Generate)
+}
+
+// Configure the build system for a profile (the current or first profile if empty).
+// The overrides are CMake cache variables set on top of the profile options.
+func (p *Project) Configure(ctx context.Context, profile string, overrides map[string]string) error {
+	variables := []string{}
+
+	for k, v := range overrides {
+		variables = append(variables, k+"="+v)
+	}
+
+	sort.Strings(variables)
+
+	return p.with(ctx, func() error {
+		// Unlike 'snake configure', the current profile is kept.
+		if len(profile) == 0 {
+			if err := p.app.Load(); err != nil {
+				return err
+			}
+
+			for _, info := range p.app.Profiles() {
+				if info.Current {
+					profile = info.Name
+				}
+			}
+		}
+
+		return p.app.Configure(application.ConfigureOptions{Profile: profile, Variables: variables})
+	})
+}
+
+// Build targets or scripts. Everything is built if no target is given.
+func (p *Project) Build(ctx context.Context, targets ...string) error {
+	return p.with(ctx, func() error {
+		return p.app.Build(application.BuildOptions{Targets: targets})
+	})
+}
+
+// Run the tests matching a regular expression (all tests if empty).
+func (p *Project) Test(ctx context.Context, filter string) error {
+	args := []string{}
+
+	if len(filter) > 0 {
+		args = append(args, filter)
+	}
+
+	return p.with(ctx, func() error {
+		return p.app.Test(application.TestOptions{Args: args})
+	})
+}
+
+// Returns the targets of the project.
+func (p *Project) Targets() ([]TargetInfo, error) {
+	if err := p.app.Load(); err != nil {
+		return nil, err
+	}
+
+	return p.app.Targets(), nil
+}
+
+// Returns the profiles of the project.
+func (p *Project) Profiles() ([]ProfileInfo, error) {
+	if err := p.app.Load(); err != nil {
+		return nil, err
+	}
+
+	return p.app.Profiles(), nil
+}
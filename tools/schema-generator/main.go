@@ -66,12 +66,21 @@ func dump(versionStr string) error {
 
 	tmpDataDir := filepath.Join(root, "distribution", "snake")
 
-	fmt.Println("Delete old zip directory:")
+	fmt.Println("Delete old zip files:")
 
-	if err = os.RemoveAll(filepath.Join(root, "distribution")); err != nil {
+	// The directory also contains the Go package embedding the archives.
+	oldZipFiles, err := filepath.Glob(filepath.Join(root, "distribution", "*.zip"))
+
+	if err != nil {
 		return err
 	}
 
+	for _, f := range append(oldZipFiles, tmpDataDir) {
+		if err = os.RemoveAll(f); err != nil {
+			return err
+		}
+	}
+
 	err = os.MkdirAll(tmpDataDir, 0777)
 
 	if err != nil {
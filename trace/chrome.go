@@ -0,0 +1,68 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package trace
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+)
+
+// ChromeEvent is a complete event of the Chrome trace-event format which can be
+// loaded in chrome://tracing, Perfetto, or Speedscope.
+type ChromeEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat,omitempty"`
+	Ph   string                 `json:"ph"`
+	Ts   float64                `json:"ts"`
+	Dur  float64                `json:"dur"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// ChromeTrace is the top-level object of the Chrome trace-event format.
+type ChromeTrace struct {
+	TraceEvents     []ChromeEvent `json:"traceEvents"`
+	DisplayTimeUnit string        `json:"displayTimeUnit"`
+}
+
+// WriteChrome converts the events to the Chrome trace-event format. Times are in
+// microseconds relative to the first command and nested commands are contained in
+// their callers so viewers display them as a flame graph.
+func WriteChrome(w io.Writer, events []Event, shorten func(file string) string) error {
+	out := ChromeTrace{TraceEvents: []ChromeEvent{}, DisplayTimeUnit: "ms"}
+
+	if len(events) > 0 {
+		start := events[0].Time
+
+		for i := range events {
+			e := &events[i]
+
+			out.TraceEvents = append(out.TraceEvents, ChromeEvent{
+				Name: strings.ToLower(e.Cmd),
+				Cat:  "cmake",
+				Ph:   "X",
+				Ts:   math.Round((e.Time - start) * 1e6),
+				Dur:  math.Round(e.Inclusive * 1e6),
+				Pid:  1,
+				Tid:  1,
+				Args: map[string]interface{}{
+					"file":  shorten(e.File),
+					"line":  e.Line,
+					"frame": e.depth(),
+					"args":  strings.Join(e.Args, " "),
+				},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+
+	return encoder.Encode(out)
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Event is a command executed by CMake (one line of a json-v1 trace).
+type Event struct {
+	// The file containing the command.
+	File string `json:"file"`
+
+	// The line of the command.
+	Line int `json:"line"`
+
+	// The command name (ex. add_library).
+	Cmd string `json:"cmd"`
+
+	// The command arguments.
+	Args []string `json:"args"`
+
+	// Time at which the command started (in seconds since the epoch).
+	Time float64 `json:"time"`
+
+	// Depth of the call stack within the file.
+	Frame int `json:"frame"`
+
+	// Depth of the call stack across files. Only available in newer CMake versions.
+	GlobalFrame int `json:"global_frame"`
+
+	// Time spent in the command including the commands it called (in seconds).
+	Inclusive float64 `json:"-"`
+
+	// Time spent in the command itself (in seconds).
+	Exclusive float64 `json:"-"`
+
+	// Index of the calling event or -1.
+	Parent int `json:"-"`
+}
+
+// Returns the call stack depth of the event.
+func (e *Event) depth() int {
+	if e.GlobalFrame > 0 {
+		return e.GlobalFrame
+	}
+
+	return e.Frame
+}
+
+// Read a json-v1 trace and compute the time spent in each command. A command ends
+// when the next command at the same or a lower depth starts.
+func Read(r io.Reader) ([]Event, error) {
+	events := []Event{}
+	scanner := bufio.NewScanner(r)
+
+	// Commands may have very long arguments.
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		var e Event
+
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+
+		// Skip the version header.
+		if len(e.Cmd) < 1 {
+			continue
+		}
+
+		e.Parent = -1
+		events = append(events, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	stack := []int{}
+
+	closeEvent := func(i int, end float64) {
+		e := &events[i]
+		e.Inclusive = end - e.Time
+		e.Exclusive += e.Inclusive
+
+		if e.Parent != -1 {
+			events[e.Parent].Exclusive -= e.Inclusive
+		}
+	}
+
+	for i := range events {
+		for len(stack) > 0 && events[stack[len(stack)-1]].depth() >= events[i].depth() {
+			closeEvent(stack[len(stack)-1], events[i].Time)
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) > 0 {
+			events[i].Parent = stack[len(stack)-1]
+		}
+
+		stack = append(stack, i)
+	}
+
+	if len(events) > 0 {
+		end := events[len(events)-1].Time
+
+		for len(stack) > 0 {
+			closeEvent(stack[len(stack)-1], end)
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return events, nil
+}
+
+// Returns the time between the first and the last command (in seconds).
+func Duration(events []Event) float64 {
+	if len(events) == 0 {
+		return 0
+	}
+
+	return events[len(events)-1].Time - events[0].Time
+}
+
+// Entry is the time spent in a group of commands.
+type Entry struct {
+	// The group name (ex. a command, a file and line, or a target).
+	Name string `json:"name"`
+
+	// Time spent including the called commands (in seconds). Recursive calls are
+	// only counted once.
+	Inclusive float64 `json:"inclusive"`
+
+	// Time spent in the commands themselves (in seconds).
+	Exclusive float64 `json:"exclusive"`
+
+	// Number of calls.
+	Calls int `json:"calls"`
+}
+
+// Group events by key. Events with an empty key are ignored.
+func aggregate(events []Event, key func(e *Event) string) []Entry {
+	entries := map[string]*Entry{}
+
+	for i := range events {
+		e := &events[i]
+		k := key(e)
+
+		if len(k) < 1 {
+			continue
+		}
+
+		entry, ok := entries[k]
+
+		if !ok {
+			entry = &Entry{Name: k}
+			entries[k] = entry
+		}
+
+		entry.Exclusive += e.Exclusive
+		entry.Calls++
+
+		// Time of nested calls with the same key is already included.
+		nested := false
+
+		for p := e.Parent; p != -1 && !nested; p = events[p].Parent {
+			nested = key(&events[p]) == k
+		}
+
+		if !nested {
+			entry.Inclusive += e.Inclusive
+		}
+	}
+
+	result := []Entry{}
+
+	for _, entry := range entries {
+		result = append(result, *entry)
+	}
+
+	return result
+}
+
+// Returns the time spent per command.
+func ByCommand(events []Event) []Entry {
+	return aggregate(events, func(e *Event) string {
+		return strings.ToLower(e.Cmd)
+	})
+}
+
+// Returns the time spent per call site (file:line).
+func ByLocation(events []Event, shorten func(file string) string) []Entry {
+	return aggregate(events, func(e *Event) string {
+		return shorten(e.File) + ":" + strconv.Itoa(e.Line) + " " + strings.ToLower(e.Cmd)
+	})
+}
+
+// Returns the time spent in the Snake macros (ex. snake_init_target).
+func ByMacro(events []Event) []Entry {
+	return aggregate(events, func(e *Event) string {
+		if cmd := strings.ToLower(e.Cmd); strings.HasPrefix(cmd, "snake_") {
+			return cmd
+		}
+
+		return ""
+	})
+}
+
+// Returns the time spent per target. A target starts with snake_init_target and
+// ends with snake_fini_target. Only the inclusive time is computed.
+func ByTarget(events []Event) []Entry {
+	entries := map[string]*Entry{}
+	start := map[string]float64{}
+
+	for i := range events {
+		e := &events[i]
+
+		if len(e.Args) < 1 {
+			continue
+		}
+
+		switch strings.ToLower(e.Cmd) {
+		case "snake_init_target":
+			start[e.Args[0]] = e.Time
+		case "snake_fini_target":
+			begin, ok := start[e.Args[0]]
+
+			if !ok {
+				continue
+			}
+
+			entry, ok := entries[e.Args[0]]
+
+			if !ok {
+				entry = &Entry{Name: e.Args[0]}
+				entries[e.Args[0]] = entry
+			}
+
+			entry.Inclusive += e.Time + e.Inclusive - begin
+			entry.Calls++
+
+			delete(start, e.Args[0])
+		}
+	}
+
+	result := []Entry{}
+
+	for _, entry := range entries {
+		result = append(result, *entry)
+	}
+
+	return result
+}
+
+// Sort entries by exclusive (or inclusive) time in decreasing order.
+func Sort(entries []Entry, inclusive bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].Exclusive, entries[j].Exclusive
+
+		if inclusive {
+			a, b = entries[i].Inclusive, entries[j].Inclusive
+		}
+
+		if a != b {
+			return a > b
+		}
+
+		return entries[i].Name < entries[j].Name
+	})
+}
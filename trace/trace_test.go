@@ -0,0 +1,161 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package trace
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The trace of a.cmake including b.cmake which includes c.cmake (times in seconds).
+const nestedTrace = `{"version":{"major":1,"minor":2}}
+{"file":"a.cmake","line":1,"cmd":"include","args":["b.cmake"],"time":0,"frame":1}
+{"file":"b.cmake","line":1,"cmd":"set","args":["X","1"],"time":1,"frame":2}
+{"file":"b.cmake","line":2,"cmd":"include","args":["c.cmake"],"time":2,"frame":2}
+{"file":"c.cmake","line":1,"cmd":"set","args":["Y","2"],"time":4,"frame":3}
+{"file":"a.cmake","line":2,"cmd":"message","args":["done"],"time":7,"frame":1}
+`
+
+// Same as nestedTrace with the frames of newer CMake versions.
+const globalFrameTrace = `{"version":{"major":1,"minor":2}}
+{"file":"a.cmake","line":1,"cmd":"include","args":["b.cmake"],"time":0,"frame":1,"global_frame":1}
+{"file":"b.cmake","line":1,"cmd":"set","args":["X","1"],"time":1,"frame":1,"global_frame":2}
+{"file":"b.cmake","line":2,"cmd":"include","args":["c.cmake"],"time":2,"frame":1,"global_frame":2}
+{"file":"c.cmake","line":1,"cmd":"set","args":["Y","2"],"time":4,"frame":1,"global_frame":3}
+{"file":"a.cmake","line":2,"cmd":"message","args":["done"],"time":7,"frame":1,"global_frame":1}
+`
+
+// The times of an event (inclusive, exclusive) and its parent.
+type timing struct {
+	Inclusive float64
+	Exclusive float64
+	Parent    int
+}
+
+func TestRead(t *testing.T) {
+	nested := []timing{{7, 1, -1}, {1, 1, 0}, {5, 2, 0}, {3, 3, 2}, {0, 0, -1}}
+
+	tests := []struct {
+		name  string
+		trace string
+		want  []timing
+		err   bool
+	}{
+		{"frames", nestedTrace, nested, false},
+		{"global frames", globalFrameTrace, nested, false},
+		{"empty", "", []timing{}, false},
+		{"header only", `{"version":{"major":1,"minor":2}}`, []timing{}, false},
+		{"invalid", "not json\n", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, err := Read(strings.NewReader(test.trace))
+
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []timing{}
+
+			for _, e := range events {
+				got = append(got, timing{e.Inclusive, e.Exclusive, e.Parent})
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+
+			if d := Duration(events); len(events) > 0 && d != 7 {
+				t.Errorf("got duration %v, want 7", d)
+			}
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	events, err := Read(strings.NewReader(nestedTrace))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shorten := func(file string) string { return strings.TrimSuffix(file, ".cmake") }
+
+	tests := []struct {
+		name      string
+		entries   []Entry
+		inclusive bool
+		want      []Entry
+	}{
+		{
+			// The nested include is only counted once in the inclusive time.
+			name:    "commands",
+			entries: ByCommand(events),
+			want: []Entry{
+				{Name: "set", Inclusive: 4, Exclusive: 4, Calls: 2},
+				{Name: "include", Inclusive: 7, Exclusive: 3, Calls: 2},
+				{Name: "message", Calls: 1},
+			},
+		},
+		{
+			name:      "locations",
+			entries:   ByLocation(events, shorten),
+			inclusive: true,
+			want: []Entry{
+				{Name: "a:1 include", Inclusive: 7, Exclusive: 1, Calls: 1},
+				{Name: "b:2 include", Inclusive: 5, Exclusive: 2, Calls: 1},
+				{Name: "c:1 set", Inclusive: 3, Exclusive: 3, Calls: 1},
+				{Name: "b:1 set", Inclusive: 1, Exclusive: 1, Calls: 1},
+				{Name: "a:2 message", Calls: 1},
+			},
+		},
+		{
+			name:    "macros",
+			entries: ByMacro(events),
+			want:    []Entry{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			Sort(test.entries, test.inclusive)
+
+			if !reflect.DeepEqual(test.entries, test.want) {
+				t.Errorf("got %+v, want %+v", test.entries, test.want)
+			}
+		})
+	}
+}
+
+func TestByTarget(t *testing.T) {
+	events, err := Read(strings.NewReader(`{"file":"CMakeLists.txt","line":1,"cmd":"snake_init_target","args":["app"],"time":0,"frame":1}
+{"file":"CMakeLists.txt","line":2,"cmd":"add_executable","args":["app"],"time":1,"frame":1}
+{"file":"CMakeLists.txt","line":3,"cmd":"snake_fini_target","args":["app"],"time":3,"frame":1}
+{"file":"CMakeLists.txt","line":4,"cmd":"snake_fini_target","args":["lib"],"time":4,"frame":1}
+{"file":"CMakeLists.txt","line":5,"cmd":"message","args":["done"],"time":6,"frame":1}
+`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The target ends with snake_fini_target. Targets without a start are ignored.
+	want := []Entry{{Name: "app", Inclusive: 4, Calls: 1}}
+
+	if got := ByTarget(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
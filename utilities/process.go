@@ -0,0 +1,131 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package utilities
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Time given to interrupted processes to exit before they are killed.
+var GracePeriod = 5 * time.Second
+
+type signalKey struct{}
+
+// The signal received by a context created with NotifyContext.
+type signalState struct {
+	mu  sync.Mutex
+	sig os.Signal
+
+	// The state of an enclosing context. Nested contexts may be cancelled by their
+	// parent before they record the signal themselves.
+	parent *signalState
+}
+
+// Returns a context cancelled when the process receives SIGINT or SIGTERM. Use
+// ContextSignal to retrieve the signal. The returned function stops listening.
+func NotifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	state := &signalState{}
+	state.parent, _ = parent.Value(signalKey{}).(*signalState)
+
+	ctx, cancel := context.WithCancel(context.WithValue(parent, signalKey{}, state))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			state.mu.Lock()
+			state.sig = sig
+			state.mu.Unlock()
+
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// Returns the signal that cancelled a context or nil.
+func ContextSignal(ctx context.Context) os.Signal {
+	state, _ := ctx.Value(signalKey{}).(*signalState)
+
+	for ; state != nil; state = state.parent {
+		state.mu.Lock()
+		sig := state.sig
+		state.mu.Unlock()
+
+		if sig != nil {
+			return sig
+		}
+	}
+
+	return nil
+}
+
+// Run a command until it exits or the context is done. Background commands run in
+// their own process group which receives the signal that cancelled the context
+// (SIGTERM if it was cancelled otherwise) and is killed after the grace period.
+// Foreground commands share the terminal with Snake and already receive SIGINT
+// from it so they are only signaled for other reasons.
+func RunCommand(ctx context.Context, cmd *exec.Cmd, foreground bool) error {
+	if !foreground {
+		setProcessGroup(cmd)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	sig := ContextSignal(ctx)
+
+	if sig == nil {
+		sig = syscall.SIGTERM
+	} else if foreground && sig == os.Interrupt {
+		return <-done
+	}
+
+	signalProcess(cmd, sig, !foreground)
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-time.After(GracePeriod):
+		signalProcess(cmd, os.Kill, !foreground)
+		err = <-done
+	}
+
+	// Children ignoring the signal (ex. background jobs of a shell) would outlive
+	// the interrupted command.
+	if !foreground {
+		signalProcess(cmd, os.Kill, true)
+	}
+
+	return err
+}
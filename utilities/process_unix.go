@@ -0,0 +1,35 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+//go:build !windows
+
+package utilities
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Start the command in a new process group so signals reach its children.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// Send a signal to a process or to its process group.
+func signalProcess(cmd *exec.Cmd, sig os.Signal, group bool) {
+	s, ok := sig.(syscall.Signal)
+
+	if !ok || !group {
+		cmd.Process.Signal(sig)
+		return
+	}
+
+	syscall.Kill(-cmd.Process.Pid, s)
+}
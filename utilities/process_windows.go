@@ -0,0 +1,29 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+//go:build windows
+
+package utilities
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Start the command in a new process group so console interrupts are not
+// delivered to it directly.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// Windows cannot deliver signals to other processes so they are killed.
+func signalProcess(cmd *exec.Cmd, sig os.Signal, group bool) {
+	cmd.Process.Kill()
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package utilities
+
+import (
+	"sort"
+	"strings"
+)
+
+// Levenshtein returns the edit distance between two strings.
+func Levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+// Suggest returns the candidates closest to a misspelled name, best match first.
+func Suggest(name string, candidates []string) []string {
+	type match struct {
+		value    string
+		distance int
+	}
+
+	matches := []match{}
+	lower := strings.ToLower(name)
+
+	for _, c := range candidates {
+		d := Levenshtein(lower, strings.ToLower(c))
+
+		// Allow roughly one typo every three characters.
+		if d <= len(name)/3+1 || strings.Contains(strings.ToLower(c), lower) {
+			matches = append(matches, match{c, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	suggestions := []string{}
+
+	for _, m := range matches {
+		suggestions = append(suggestions, m.value)
+	}
+
+	return suggestions
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+package utilities
+
+import "path/filepath"
+
+// WatchOp describes what happened to a watched file.
+type WatchOp int
+
+const (
+	// The file content changed.
+	WatchWrite WatchOp = 1 << iota
+
+	// The file was created or moved into a watched directory.
+	WatchCreate
+
+	// The file was deleted or moved out of a watched directory.
+	WatchRemove
+)
+
+// WatchEvent is a change to a watched file.
+type WatchEvent struct {
+	// Path to the changed file.
+	Path string
+
+	// The kind of change.
+	Op WatchOp
+}
+
+// Watcher reports changes to files and directories. Directories are watched
+// recursively. It uses inotify on Linux and polling everywhere else.
+type Watcher struct {
+	// Received file changes.
+	Events chan WatchEvent
+
+	// Received errors.
+	Errors chan error
+
+	// Directories that are never watched.
+	excluded map[string]bool
+
+	backend
+}
+
+// Create a new file watcher. You must call Close when done.
+func NewWatcher() (*Watcher, error) {
+	w := &Watcher{
+		Events:   make(chan WatchEvent),
+		Errors:   make(chan error),
+		excluded: map[string]bool{},
+	}
+
+	if err := w.start(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Ignore a directory and its content. Only applies to the paths added afterwards.
+func (w *Watcher) Exclude(path string) error {
+	path, err := filepath.Abs(path)
+
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.excluded[path] = true
+
+	return nil
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+//go:build linux
+
+package utilities
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF
+
+// Inotify backend.
+type backend struct {
+	mu   sync.Mutex
+	done chan struct{}
+	file *os.File
+	fd   int
+
+	// Map of watch descriptors to directories.
+	dirs map[int]string
+
+	// Map of directories to watch descriptors.
+	wds map[string]int
+
+	// Directories watched recursively.
+	recursive map[string]bool
+
+	// Files watched individually (their parent directory is watched).
+	files map[string]bool
+}
+
+func (w *Watcher) start() error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+
+	if err != nil {
+		return os.NewSyscallError("inotify_init1", err)
+	}
+
+	// A non-blocking file uses the runtime poller so Close interrupts Read.
+	w.fd = fd
+	w.done = make(chan struct{})
+	w.file = os.NewFile(uintptr(fd), "inotify")
+	w.dirs = map[int]string{}
+	w.wds = map[string]int{}
+	w.recursive = map[string]bool{}
+	w.files = map[string]bool{}
+
+	go w.read()
+
+	return nil
+}
+
+func (w *Watcher) watchDir(dir string) error {
+	if _, ok := w.wds[dir]; ok {
+		return nil
+	}
+
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	w.dirs[wd] = dir
+	w.wds[dir] = wd
+
+	return nil
+}
+
+func (w *Watcher) watchTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+
+		if w.excluded[path] {
+			return filepath.SkipDir
+		}
+
+		w.recursive[path] = true
+
+		return w.watchDir(path)
+	})
+}
+
+// Watch a file or a directory (recursively).
+func (w *Watcher) Add(path string) error {
+	path, err := filepath.Abs(path)
+
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if info.IsDir() {
+		return w.watchTree(path)
+	}
+
+	// Editors often replace files so we watch the parent directory instead.
+	w.files[path] = true
+
+	return w.watchDir(filepath.Dir(path))
+}
+
+func (w *Watcher) read() {
+	defer close(w.Events)
+	defer close(w.Errors)
+
+	buffer := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+
+	for {
+		n, err := w.file.Read(buffer)
+
+		if errors.Is(err, os.ErrClosed) {
+			return
+		} else if err != nil {
+			select {
+			case w.Errors <- err:
+			case <-w.done:
+			}
+
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+			nameBytes := buffer[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			name := string(nameBytes)
+
+			for i, c := range nameBytes {
+				if c == 0 {
+					name = string(nameBytes[:i])
+					break
+				}
+			}
+
+			if e, ok := w.translate(event, name); ok {
+				select {
+				case w.Events <- e:
+				case <-w.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Convert an inotify event and return false if the event is not interesting.
+func (w *Watcher) translate(event *syscall.InotifyEvent, name string) (WatchEvent, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dir, ok := w.dirs[int(event.Wd)]
+
+	if !ok {
+		return WatchEvent{}, false
+	}
+
+	if event.Mask&syscall.IN_DELETE_SELF != 0 {
+		delete(w.dirs, int(event.Wd))
+		delete(w.wds, dir)
+		return WatchEvent{}, false
+	}
+
+	path := filepath.Join(dir, name)
+
+	if (!w.recursive[dir] && !w.files[path]) || w.excluded[path] {
+		return WatchEvent{}, false
+	}
+
+	e := WatchEvent{Path: path, Op: WatchWrite}
+
+	switch {
+	case event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		e.Op = WatchCreate
+
+		if event.Mask&syscall.IN_ISDIR != 0 && w.recursive[dir] {
+			if err := w.watchTree(path); err != nil {
+				return WatchEvent{}, false
+			}
+		}
+	case event.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+		e.Op = WatchRemove
+	}
+
+	return e, true
+}
+
+// Stop watching and close the event channels.
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.file.Close()
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2022-2024 Sumartian Studios
+//
+// Snake is free software: you can redistribute it and/or modify it under the
+// terms of the MIT license.
+
+//go:build !linux
+
+package utilities
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// How often the watched paths are scanned.
+const pollInterval = 500 * time.Millisecond
+
+// Polling backend.
+type backend struct {
+	mu    sync.Mutex
+	done  chan struct{}
+	roots map[string]bool
+	state map[string]time.Time
+}
+
+func (w *Watcher) start() error {
+	w.done = make(chan struct{})
+	w.roots = map[string]bool{}
+	w.state = map[string]time.Time{}
+
+	go w.poll()
+
+	return nil
+}
+
+// Watch a file or a directory (recursively).
+func (w *Watcher) Add(path string) error {
+	path, err := filepath.Abs(path)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.roots[path] = true
+
+	for file, modified := range w.scan(path) {
+		w.state[file] = modified
+	}
+
+	return nil
+}
+
+func (w *Watcher) scan(root string) map[string]time.Time {
+	files := map[string]time.Time{}
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && w.excluded[path] {
+			return filepath.SkipDir
+		}
+
+		if err != nil || d.IsDir() {
+			return nil
+		}
+
+		if info, err := d.Info(); err == nil {
+			files[path] = info.ModTime()
+		}
+
+		return nil
+	})
+
+	return files
+}
+
+func (w *Watcher) poll() {
+	defer close(w.Events)
+	defer close(w.Errors)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.Lock()
+
+		current := map[string]time.Time{}
+
+		for root := range w.roots {
+			for file, modified := range w.scan(root) {
+				current[file] = modified
+			}
+		}
+
+		events := []WatchEvent{}
+
+		for file, modified := range current {
+			if previous, ok := w.state[file]; !ok {
+				events = append(events, WatchEvent{Path: file, Op: WatchCreate})
+			} else if !previous.Equal(modified) {
+				events = append(events, WatchEvent{Path: file, Op: WatchWrite})
+			}
+		}
+
+		for file := range w.state {
+			if _, ok := current[file]; !ok {
+				events = append(events, WatchEvent{Path: file, Op: WatchRemove})
+			}
+		}
+
+		w.state = current
+		w.mu.Unlock()
+
+		for _, e := range events {
+			select {
+			case w.Events <- e:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// Stop watching and close the event channels.
+func (w *Watcher) Close() error {
+	close(w.done)
+	return nil
+}